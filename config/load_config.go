@@ -3,13 +3,23 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/may20xx/booking/pkg/log"
 )
 
+// Development is the APP_ENV that allows shortcuts unsafe in production,
+// such as signing access tokens with an ephemeral key.
+const Development = "development"
+
 type Config struct {
-	Port string
+	Environment string
+
+	Port      string
+	ServerURL string
+	ClientURL string
 
 	DBPort     string
 	DBHost     string
@@ -17,7 +27,12 @@ type Config struct {
 	DBPassword string
 	DBName     string
 
-	JWTSecret           string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	JWTKeysDir          string
+	JWTSigningKeyID     string
 	JWTRefreshSecret    string
 	CloudinaryCloudName string
 	CloudinaryAPIKey    string
@@ -27,6 +42,29 @@ type Config struct {
 	MailPort int
 	MailUser string
 	MailPass string
+
+	PaymentProvider  string
+	PaymentSecret    string
+	PaymentCurrency  string
+	PaymentAllowFake bool
+
+	Geocoder string
+
+	OIDCProviders []OIDCProvider
+}
+
+// OIDCProvider configures sign-in with an external identity provider. GitHub
+// needs no issuer; any other provider is an OpenID Connect issuer.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+}
+
+// defaultIssuers lets well-known providers be enabled by name alone.
+var defaultIssuers = map[string]string{
+	"google": "https://accounts.google.com",
 }
 
 var config *Config
@@ -40,14 +78,23 @@ func init() {
 }
 
 func loadConfig() *Config {
-	return &Config{
-		Port:                getEnv("PORT", "8080"),
+	port := getEnv("PORT", "8080")
+
+	setting := &Config{
+		Environment:         getEnv("APP_ENV", "production"),
+		Port:                port,
+		ServerURL:           getEnv("SERVER_URL", "http://localhost:"+port),
+		ClientURL:           getEnv("CLIENT_URL", "http://localhost:3000"),
 		DBPort:              getEnv("DB_PORT", "5432"),
 		DBHost:              getEnv("DB_HOST", "localhost"),
 		DBUser:              getEnv("DB_USERNAME", "postgres"),
 		DBPassword:          getEnv("DB_PASSWORD", "postgres"),
 		DBName:              getEnv("DB_NAME", "postgres"),
-		JWTSecret:           getEnvMustExist("JWT_SECRET"),
+		RedisAddr:           getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:       getEnv("REDIS_PASSWORD", ""),
+		RedisDB:             getEnvInt("REDIS_DB", 0),
+		JWTKeysDir:          getEnv("JWT_KEYS_DIR", ""),
+		JWTSigningKeyID:     getEnv("JWT_SIGNING_KEY_ID", ""),
 		JWTRefreshSecret:    getEnvMustExist("JWT_REFRESH_SECRET"),
 		CloudinaryCloudName: getEnvMustExist("CLOUDINARY_CLOUD_NAME"),
 		CloudinaryAPIKey:    getEnvMustExist("CLOUDINARY_API_KEY"),
@@ -56,7 +103,50 @@ func loadConfig() *Config {
 		MailPort:            587,
 		MailUser:            getEnvMustExist("MAIL_USER"),
 		MailPass:            getEnvMustExist("MAIL_PASS"),
+		PaymentProvider:     getEnv("PAYMENT_PROVIDER", "fake"),
+		PaymentSecret:       getEnvNotEmpty("PAYMENT_SECRET"),
+		PaymentCurrency:     getEnv("PAYMENT_CURRENCY", "USD"),
+		PaymentAllowFake:    getEnv("PAYMENT_ALLOW_FAKE", "") == "true",
+		Geocoder:            getEnv("GEOCODER", "fixture"),
+		OIDCProviders:       loadOIDCProviders(),
+	}
+
+	// The fake gateway forgets its payments on restart, so bookings paid
+	// before one could no longer be refunded. Outside development it has to
+	// be asked for explicitly.
+	if (setting.PaymentProvider == "" || setting.PaymentProvider == "fake") && !setting.IsDevelopment() {
+		if !setting.PaymentAllowFake {
+			log.Msg.Fatal("PAYMENT_PROVIDER=fake keeps payments in memory, set PAYMENT_ALLOW_FAKE=true to use it outside development")
+		}
+		log.Msg.Warn("Taking payments with the fake gateway, which forgets them on restart")
+	}
+
+	return setting
+}
+
+// loadOIDCProviders reads the comma separated OIDC_PROVIDERS list and, for
+// each name, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and optionally
+// OIDC_<NAME>_ISSUER.
+func loadOIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", defaultIssuers[name]),
+			ClientID:     getEnvMustExist(prefix + "CLIENT_ID"),
+			ClientSecret: getEnvMustExist(prefix + "CLIENT_SECRET"),
+		})
 	}
+
+	return providers
 }
 
 func getEnv(key, fallback string) string {
@@ -66,6 +156,19 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Msg.Fatal(fmt.Sprintf("%s must be a number", key))
+	}
+	return number
+}
+
 func getEnvMustExist(key string) string {
 	value, exists := os.LookupEnv(key)
 	if !exists {
@@ -74,6 +177,21 @@ func getEnvMustExist(key string) string {
 	return value
 }
 
+// getEnvNotEmpty is getEnvMustExist for secrets, where an empty value would
+// be as easy to guess as a missing one.
+func getEnvNotEmpty(key string) string {
+	value := getEnvMustExist(key)
+	if value == "" {
+		log.Msg.Fatal(fmt.Sprintf("%s must not be empty", key))
+	}
+	return value
+}
+
+// IsDevelopment reports whether APP_ENV explicitly asks for development.
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == Development
+}
+
 func GetConfig() *Config {
 	return config
 }
@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/may20xx/booking/internal/domain"
+
+type RoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin manager"`
+}
+
+type UserRolesResponse struct {
+	User  *domain.Landlord `json:"user"`
+	Roles []domain.Role    `json:"roles"`
+}
@@ -4,6 +4,12 @@ const (
 	AccessToken        = "access_token"
 	RefreshToken       = "refresh_token"
 	ConfirmAccessToken = "confirm_access_token"
+	PasswordResetToken = "password_reset_token"
+	EmailChangeToken   = "email_change_token"
+	UnlockAccountToken = "unlock_account_token"
+	TwoFactorToken     = "two_factor_token"
+	RecoveryCode       = "recovery_code"
+	AccountDeleteToken = "account_delete_token"
 
 	User    = "user"
 	Admin   = "admin"
@@ -22,3 +28,26 @@ type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// ClientInfo describes the device a request comes from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
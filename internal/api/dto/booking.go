@@ -0,0 +1,32 @@
+package dto
+
+import "github.com/may20xx/booking/internal/domain"
+
+const DateLayout = "2006-01-02"
+
+type BookingRequest struct {
+	ListingID     int     `json:"listing_id" validate:"required"`
+	StartDate     string  `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate       string  `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Guests        int     `json:"guests" validate:"required,min=1"`
+	PhoneNumber   *string `json:"phone_number"`
+	MessageToHost *string `json:"message_to_host"`
+}
+
+type QuoteResponse struct {
+	ListingID   int                 `json:"listing_id"`
+	StartDate   string              `json:"start_date"`
+	EndDate     string              `json:"end_date"`
+	Nights      int                 `json:"nights"`
+	Guests      int                 `json:"guests"`
+	Available   bool                `json:"available"`
+	PriceDetail *domain.PriceDetail `json:"price_detail"`
+}
+
+type CancelPreview struct {
+	BookingID     int                        `json:"booking_id"`
+	Policy        *domain.CancellationPolicy `json:"policy"`
+	RefundPercent int                        `json:"refund_percent"`
+	ChargedAmount float64                    `json:"charged_amount"`
+	RefundAmount  float64                    `json:"refund_amount"`
+}
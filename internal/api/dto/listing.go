@@ -1,15 +1,90 @@
 package dto
 
+import "github.com/may20xx/booking/internal/domain"
+
+// ListingRequest creates or updates a listing. Latitude and Longitude pin it
+// on the map; without them its location is geocoded.
 type ListingRequest struct {
-	Title       string  `json:"title" db:"title"`
-	Description string  `json:"description" db:"description"`
-	Catalogs    []int   `json:"catalogs" db:"-"`
-	Location    string  `json:"location" db:"location"`
-	Guests      int     `json:"guests" db:"guests"`
-	Beds        int     `json:"beds" db:"beds"`
-	Baths       int     `json:"baths" db:"baths"`
-	Price       float64 `json:"price" db:"price"`
-	CleaningFee float64 `json:"cleaning_fee" db:"cleaning_fee"`
-	ServiceFee  float64 `json:"service_fee" db:"service_fee"`
-	Taxes       float64 `json:"taxes" db:"taxes"`
+	Title       string   `json:"title" db:"title"`
+	Description string   `json:"description" db:"description"`
+	Catalogs    []int    `json:"catalogs" db:"-"`
+	Location    string   `json:"location" db:"location"`
+	Latitude    *float64 `json:"latitude" db:"latitude"`
+	Longitude   *float64 `json:"longitude" db:"longitude"`
+	Guests      int      `json:"guests" db:"guests"`
+	Beds        int      `json:"beds" db:"beds"`
+	Baths       int      `json:"baths" db:"baths"`
+	Price       float64  `json:"price" db:"price"`
+	CleaningFee float64  `json:"cleaning_fee" db:"cleaning_fee"`
+	ServiceFee  float64  `json:"service_fee" db:"service_fee"`
+	Taxes       float64  `json:"taxes" db:"taxes"`
+}
+
+type CancellationPolicyRequest struct {
+	Name              string `json:"name" validate:"required,oneof=flexible moderate strict"`
+	CutoffDays        *int   `json:"cutoff_days" validate:"omitempty,min=0,max=365"`
+	RefundPercent     *int   `json:"refund_percent" validate:"omitempty,min=0,max=100"`
+	LateRefundPercent *int   `json:"late_refund_percent" validate:"omitempty,min=0,max=100"`
+}
+
+// PriceRulesRequest replaces every price rule of a listing. WeekendPercent
+// is added to Friday and Saturday nights, each season charges its own
+// nightly price, and the discounts are taken off stays of at least a week
+// or four weeks.
+type PriceRulesRequest struct {
+	WeekendPercent  *float64         `json:"weekend_percent" validate:"omitempty,min=0,max=500"`
+	WeeklyDiscount  *float64         `json:"weekly_discount" validate:"omitempty,min=0,lt=100"`
+	MonthlyDiscount *float64         `json:"monthly_discount" validate:"omitempty,min=0,lt=100"`
+	Seasons         []*SeasonRequest `json:"seasons" validate:"max=50,dive,required"`
+}
+
+type SeasonRequest struct {
+	StartDate string  `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string  `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Price     float64 `json:"price" validate:"gt=0"`
+}
+
+// BlockDatesRequest is a range of nights a host blocks or unblocks, from
+// StartDate up to but not including EndDate.
+type BlockDatesRequest struct {
+	StartDate string `json:"start_date" query:"start" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" query:"end" validate:"required,datetime=2006-01-02"`
+}
+
+type CalendarResponse struct {
+	ListingID int                   `json:"listing_id"`
+	StartDate string                `json:"start_date"`
+	EndDate   string                `json:"end_date"`
+	Days      []*domain.CalendarDay `json:"days"`
+}
+
+// ListingSearchRequest is the query string of GET /search. Text is searched
+// for in the title, location and description, while Query only filters on
+// the location. Catalogs is a comma-separated list of catalog ids, any of
+// which a listing must be in. Latitude and Longitude search within Radius
+// kilometres of a point, and Bounds, given as "south,west,north,east",
+// within a map view. Zoom is only used by GET /search/clusters.
+// Results are paged by page number unless a cursor is given or paginate is
+// "cursor".
+type ListingSearchRequest struct {
+	Text      string   `query:"q"`
+	Query     string   `query:"s"`
+	MinPrice  *float64 `query:"min_price" validate:"omitempty,min=0"`
+	MaxPrice  *float64 `query:"max_price" validate:"omitempty,min=0"`
+	Guests    int      `query:"guests" validate:"min=0"`
+	Beds      int      `query:"beds" validate:"min=0"`
+	Baths     int      `query:"baths" validate:"min=0"`
+	Catalogs  string   `query:"catalogs"`
+	Start     string   `query:"start"`
+	End       string   `query:"end"`
+	Latitude  *float64 `query:"lat" validate:"omitempty,min=-90,max=90"`
+	Longitude *float64 `query:"lng" validate:"omitempty,min=-180,max=180"`
+	Radius    float64  `query:"radius" validate:"min=0,max=100"`
+	Bounds    string   `query:"bbox"`
+	Zoom      int      `query:"zoom" validate:"min=0,max=20"`
+	Sort      string   `query:"sort" validate:"omitempty,oneof=newest price_asc price_desc rating relevance distance"`
+	Page      int      `query:"page" validate:"min=0"`
+	Limit     int      `query:"limit" validate:"min=0,max=100"`
+	Cursor    string   `query:"cursor"`
+	Paginate  string   `query:"paginate" validate:"omitempty,oneof=offset cursor"`
 }
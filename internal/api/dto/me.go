@@ -1,7 +1,51 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/may20xx/booking/internal/domain"
+)
+
 type UpdateProfileRequest struct {
-	Email     string `json:"email" validate:"required,email"`
 	FirstName string `json:"firstName" validate:"required,alpha"`
 	Surname   string `json:"surname" validate:"required,alpha"`
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,nefield=CurrentPassword"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// DeleteAccountRequest confirms an account deletion with the password, or for
+// accounts signed up through an identity provider, with the token mailed by
+// the deletion request. Code is only needed when two-factor authentication is
+// enabled.
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required_without=Token"`
+	Token    string `json:"token" validate:"required_without=Password"`
+	Code     string `json:"code"`
+}
+
+// PersonalDataExport is everything the service stores about a user, as handed
+// out by GET /me/export.
+type PersonalDataExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Profile    *domain.User           `json:"profile"`
+	Identities []*domain.UserIdentity `json:"identities"`
+	Sessions   []*domain.Session      `json:"sessions"`
+	Listings   []*domain.Listing      `json:"listings"`
+	Bookings   []*domain.Booking      `json:"bookings"`
+	Reviews    []*ExportedReview      `json:"reviews"`
+}
+
+// ExportedReview adds the references a review hides from the public API.
+type ExportedReview struct {
+	ListingID int `json:"listing_id"`
+	BookingID int `json:"booking_id"`
+	*domain.Review
+}
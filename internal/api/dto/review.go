@@ -0,0 +1,12 @@
+package dto
+
+type ReviewRequest struct {
+	BookingID int    `json:"booking_id" validate:"required"`
+	Rating    int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment   string `json:"comment" validate:"required,max=2000"`
+}
+
+type UpdateReviewRequest struct {
+	Rating  int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"required,max=2000"`
+}
@@ -4,7 +4,9 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/may20xx/booking/internal/handler"
 	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
 	"github.com/samber/lo"
 )
 
@@ -27,6 +29,16 @@ func AuthGuard() fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(utils.NewAppError(401, "Invalid token"))
 		}
 
+		revoked, err := handler.IsRevoked(payload)
+		if err != nil {
+			log.Msg.Error(err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(utils.NewAppError(503, "Service unavailable"))
+		}
+
+		if revoked {
+			return c.Status(fiber.StatusUnauthorized).JSON(utils.NewAppError(401, "Token has been revoked"))
+		}
+
 		c.Locals("user", payload)
 
 		return c.Next()
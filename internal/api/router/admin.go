@@ -0,0 +1,88 @@
+package router
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/api/middleware/guard"
+	"github.com/may20xx/booking/internal/handler"
+	"github.com/may20xx/booking/internal/utils"
+)
+
+type adminRouter struct {
+	validate *validator.Validate
+	service  handler.AdminService
+}
+
+func newAdminRouter() *adminRouter {
+	return &adminRouter{
+		validate: validator.New(),
+		service:  handler.NewAdminService(),
+	}
+}
+
+func (r *adminRouter) findRoles(c *fiber.Ctx) error {
+	res, err := r.service.FindRoles()
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *adminRouter) findUserRoles(c *fiber.Ctx) error {
+	res, err := r.service.FindUserRoles(c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *adminRouter) grantRole(c *fiber.Ctx) error {
+	req := new(dto.RoleRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.GrantRole(c.Params("id"), req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *adminRouter) revokeRole(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.RevokeRole(payload, c.Params("id"), c.Params("role"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func AdminRouter(router fiber.Router) {
+	routes := newAdminRouter()
+
+	admin := router.Group("/admin", guard.AuthGuard(), guard.AuthorizeRoles(dto.Admin))
+
+	admin.Get("/roles", routes.findRoles)
+	admin.Get("/users/:id/roles", routes.findUserRoles)
+	admin.Post("/users/:id/roles", routes.grantRole)
+	admin.Delete("/users/:id/roles/:role", routes.revokeRole)
+}
@@ -1,8 +1,11 @@
 package router
 
 import (
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 
 	"github.com/may20xx/booking/internal/api/dto"
 	"github.com/may20xx/booking/internal/handler"
@@ -52,7 +55,58 @@ func (r *authRouter) login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
 	}
 
-	result, err := r.service.LoginHandler(req)
+	result, err := r.service.LoginHandler(req, clientInfo(c))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewResponse(fiber.StatusOK, result))
+}
+
+func (r *authRouter) verifyTwoFactor(c *fiber.Ctx) error {
+	req := new(dto.TwoFactorVerifyRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	result, err := r.service.VerifyTwoFactorHandler(req, clientInfo(c))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewResponse(fiber.StatusOK, result))
+}
+
+func (r *authRouter) oidcLogin(c *fiber.Ctx) error {
+	url, err := r.service.OIDCAuthURLHandler(c.Params("provider"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Redirect(url, fiber.StatusFound)
+}
+
+func (r *authRouter) oidcCallback(c *fiber.Ctx) error {
+	if c.Query("error") != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(utils.NewAppError(401, "External sign-in was cancelled"))
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+
+	if code == "" || state == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	result, err := r.service.OIDCCallbackHandler(c.Params("provider"), code, state, clientInfo(c))
 
 	if err != nil {
 		return c.Status(err.Code).JSON(err)
@@ -78,10 +132,149 @@ func (r *authRouter) verifyEmail(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(200, "Confirm account successfully!"))
 }
 
+func (r *authRouter) confirmEmail(c *fiber.Ctx) error {
+	token := c.Query("token")
+
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid token"))
+	}
+
+	err := r.service.ConfirmEmailChangeHandler(token)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(200, "Confirm email successfully!"))
+}
+
+func (r *authRouter) unlock(c *fiber.Ctx) error {
+	token := c.Query("token")
+
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid token"))
+	}
+
+	err := r.service.UnlockAccountHandler(token)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(200, "Unlock account successfully!"))
+}
+
+func (r *authRouter) refresh(c *fiber.Ctx) error {
+	req := new(dto.RefreshRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	result, err := r.service.RefreshHandler(req, clientInfo(c))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewResponse(fiber.StatusOK, result))
+}
+
+func (r *authRouter) resendVerification(c *fiber.Ctx) error {
+	req := new(dto.ResendVerificationRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.ResendVerificationHandler(req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(200, "If the account exists and is not verified, a verification email has been sent"))
+}
+
+func (r *authRouter) forgotPassword(c *fiber.Ctx) error {
+	req := new(dto.ForgotPasswordRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.ForgotPasswordHandler(req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(200, "If the account exists, a password reset email has been sent"))
+}
+
+func (r *authRouter) resetPassword(c *fiber.Ctx) error {
+	req := new(dto.ResetPasswordRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.ResetPasswordHandler(req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(200, "Reset password successfully!"))
+}
+
+func clientInfo(c *fiber.Ctx) *dto.ClientInfo {
+	return &dto.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
+
+// mailLimiter throttles the endpoints that send emails per client IP.
+func mailLimiter() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        5,
+		Expiration: 15 * time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(utils.NewAppError(429, "Too many requests, please try again later"))
+		},
+	})
+}
+
 func AuthRouter(router fiber.Router) {
 	routes := newAuthRouter()
 
 	router.Post("/auth/register", routes.register)
 	router.Post("/auth/login", routes.login)
+	router.Post("/auth/2fa/verify", routes.verifyTwoFactor)
+	router.Get("/auth/oidc/:provider", routes.oidcLogin)
+	router.Get("/auth/oidc/:provider/callback", routes.oidcCallback)
+	router.Post("/auth/refresh", routes.refresh)
 	router.Get("/auth/confirm-account", routes.verifyEmail)
+	router.Get("/auth/confirm-email", routes.confirmEmail)
+	router.Get("/auth/unlock", routes.unlock)
+	router.Post("/auth/resend-verification", mailLimiter(), routes.resendVerification)
+	router.Post("/auth/forgot-password", mailLimiter(), routes.forgotPassword)
+	router.Post("/auth/reset-password", routes.resetPassword)
 }
@@ -3,7 +3,10 @@ package router
 import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/api/middleware/guard"
 	"github.com/may20xx/booking/internal/handler"
+	"github.com/may20xx/booking/internal/utils"
 )
 
 type bookingRouter struct {
@@ -18,12 +21,186 @@ func newBookingRouter() *bookingRouter {
 	}
 }
 
+func (r *bookingRouter) save(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.BookingRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.Save(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
 func (r *bookingRouter) findAll(c *fiber.Ctx) error {
-	return nil
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	page := c.Query("page")
+	limit := c.Query("limit")
+
+	res, err := r.service.FindAllForUser(payload, page, limit)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) findDetail(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	id := c.Params("id")
+
+	res, err := r.service.FindDetail(payload, id)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) findAllForListing(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	id := c.Params("id")
+	page := c.Query("page")
+	limit := c.Query("limit")
+
+	res, err := r.service.FindAllForListing(payload, id, page, limit)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) quote(c *fiber.Ctx) error {
+	id := c.Params("id")
+	start := c.Query("start")
+	end := c.Query("end")
+	guests := c.Query("guests")
+
+	res, err := r.service.Quote(id, start, end, guests)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) accept(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.Accept(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) decline(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.Decline(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) complete(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.Complete(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) cancel(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.Cancel(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *bookingRouter) cancelPreview(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.CancelPreview(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
 }
 
 func BookingRouter(router fiber.Router) {
 	routes := newBookingRouter()
 
-	router.Get("/bookings", routes.findAll)
+	router.Get("/bookings", guard.AuthGuard(), routes.findAll)
+	router.Post("/bookings", guard.AuthGuard(), routes.save)
+	router.Get("/bookings/:id", guard.AuthGuard(), routes.findDetail)
+	router.Put("/bookings/:id/accept", guard.AuthGuard(), routes.accept)
+	router.Put("/bookings/:id/decline", guard.AuthGuard(), routes.decline)
+	router.Put("/bookings/:id/complete", guard.AuthGuard(), routes.complete)
+	router.Get("/bookings/:id/cancel-preview", guard.AuthGuard(), routes.cancelPreview)
+	router.Put("/bookings/:id/cancel", guard.AuthGuard(), routes.cancel)
+	router.Get("/listings/:id/bookings", guard.AuthGuard(), routes.findAllForListing)
+	router.Get("/listings/:id/quote", routes.quote)
 }
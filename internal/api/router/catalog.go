@@ -4,6 +4,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/api/middleware/guard"
 	"github.com/may20xx/booking/internal/handler"
 	"github.com/may20xx/booking/internal/utils"
 )
@@ -105,8 +106,8 @@ func CatalogRouter(r fiber.Router) {
 	routes := newCatalogRoutes()
 
 	r.Get("/catalogs", routes.findAll)
-	r.Post("/catalogs", routes.save)
+	r.Post("/catalogs", guard.AuthGuard(), guard.AuthorizeRoles(dto.Admin, dto.Manager), routes.save)
 	r.Get("/catalogs/:id", routes.findDetail)
-	r.Put("/catalogs/:id", routes.update)
-	r.Delete("/catalogs/:id", routes.delete)
+	r.Put("/catalogs/:id", guard.AuthGuard(), guard.AuthorizeRoles(dto.Admin, dto.Manager), routes.update)
+	r.Delete("/catalogs/:id", guard.AuthGuard(), guard.AuthorizeRoles(dto.Admin, dto.Manager), routes.delete)
 }
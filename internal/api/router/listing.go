@@ -92,7 +92,7 @@ func (r *listingRouter) update(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
 	}
 
-	res, err := r.service.Update(id, req)
+	res, err := r.service.Update(payload, id, req)
 
 	if err != nil {
 		return c.Status(err.Code).JSON(err)
@@ -108,7 +108,7 @@ func (r *listingRouter) remove(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
 	}
 
-	res, err := r.service.Remove(id)
+	res, err := r.service.Remove(payload, id)
 
 	if err != nil {
 		return c.Status(err.Code).JSON(err)
@@ -130,12 +130,234 @@ func (r *listingRouter) findAll(c *fiber.Ctx) error {
 	return c.JSON(result)
 }
 
-func (r *listingRouter) searchByLocation(c *fiber.Ctx) error {
-	query := c.Query("s")
-	page := c.Query("page")
-	limit := c.Query("limit")
+func (r *listingRouter) search(c *fiber.Ctx) error {
+	req := new(dto.ListingSearchRequest)
+
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid query"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	if req.Cursor != "" || req.Paginate == "cursor" {
+		res, err := r.service.SearchAfter(req)
+
+		if err != nil {
+			return c.Status(err.Code).JSON(err)
+		}
+
+		return c.JSON(res)
+	}
+
+	res, err := r.service.Search(req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) clusters(c *fiber.Ctx) error {
+	req := new(dto.ListingSearchRequest)
+
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid query"))
+	}
 
-	res, err := r.service.Search(page, limit, query)
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.Clusters(req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) suggest(c *fiber.Ctx) error {
+	res, err := r.service.Suggest(c.Query("q"), c.Query("limit"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) findCancellationPolicy(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	res, err := r.service.FindCancellationPolicy(id)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) updateCancellationPolicy(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.CancellationPolicyRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.UpdateCancellationPolicy(payload, id, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) findPriceRules(c *fiber.Ctx) error {
+	res, err := r.service.FindPriceRules(c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) updatePriceRules(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.PriceRulesRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.UpdatePriceRules(payload, c.Params("id"), req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) calendar(c *fiber.Ctx) error {
+	res, err := r.service.Calendar(c.Params("id"), c.Query("from"), c.Query("months"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) blockDates(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.BlockDatesRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.BlockDates(payload, c.Params("id"), req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (r *listingRouter) unblockDates(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.BlockDatesRequest)
+
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid query"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.UnblockDates(payload, c.Params("id"), req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *listingRouter) savePhotos(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	form, err := c.MultipartForm()
+
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid form data!"))
+	}
+
+	img, err := validationPhotos(form.File["photos"])
+
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	res, ext := r.service.SavePhotos(payload, c.Params("id"), img)
+
+	if ext != nil {
+		return c.Status(ext.Code).JSON(ext)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (r *listingRouter) removePhoto(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.RemovePhoto(payload, c.Params("id"), c.Params("photoId"))
 
 	if err != nil {
 		return c.Status(err.Code).JSON(err)
@@ -148,11 +370,22 @@ func ListingRouter(router fiber.Router) {
 	routes := newListingRouter()
 
 	router.Get("/listings", newListingRouter().findAll)
-	router.Get("/search", newListingRouter().searchByLocation)
+	router.Get("/search", routes.search)
+	router.Get("/search/suggest", routes.suggest)
+	router.Get("/search/clusters", routes.clusters)
 	router.Get("/listings/:id", newListingRouter().findDetail)
 	router.Post("/listings", guard.AuthGuard(), routes.save)
 	router.Put("/listings/:id", guard.AuthGuard(), newListingRouter().update)
 	router.Delete("/listings/:id", guard.AuthGuard(), newListingRouter().remove)
+	router.Post("/listings/:id/photos", guard.AuthGuard(), routes.savePhotos)
+	router.Delete("/listings/:id/photos/:photoId", guard.AuthGuard(), routes.removePhoto)
+	router.Get("/listings/:id/cancellation-policy", routes.findCancellationPolicy)
+	router.Put("/listings/:id/cancellation-policy", guard.AuthGuard(), routes.updateCancellationPolicy)
+	router.Get("/listings/:id/price-rules", routes.findPriceRules)
+	router.Put("/listings/:id/price-rules", guard.AuthGuard(), routes.updatePriceRules)
+	router.Get("/listings/:id/calendar", routes.calendar)
+	router.Post("/listings/:id/blocked-dates", guard.AuthGuard(), routes.blockDates)
+	router.Delete("/listings/:id/blocked-dates", guard.AuthGuard(), routes.unblockDates)
 }
 
 // Validation
@@ -218,6 +451,17 @@ func validationFormData(form *multipart.Form) (*dto.ListingRequest, error) {
 		return floatValue, nil
 	}
 
+	getOptionalFloatValue := func(key string) (*float64, error) {
+		if _, ok := getFirstValue(key); !ok {
+			return nil, nil
+		}
+		floatValue, err := getFloatValue(key)
+		if err != nil {
+			return nil, err
+		}
+		return &floatValue, nil
+	}
+
 	convertStrToInt := func(arr []string) ([]int, error) {
 		seen := make(map[int]bool)
 		var result []int
@@ -291,10 +535,22 @@ func validationFormData(form *multipart.Form) (*dto.ListingRequest, error) {
 		return &dto.ListingRequest{}, utils.NewAppError(400, err.Error())
 	}
 
+	latitude, err := getOptionalFloatValue("latitude")
+	if err != nil {
+		return &dto.ListingRequest{}, utils.NewAppError(400, err.Error())
+	}
+
+	longitude, err := getOptionalFloatValue("longitude")
+	if err != nil {
+		return &dto.ListingRequest{}, utils.NewAppError(400, err.Error())
+	}
+
 	request := &dto.ListingRequest{
 		Title:       title,
 		Description: description,
 		Location:    location,
+		Latitude:    latitude,
+		Longitude:   longitude,
 		Guests:      guests,
 		Beds:        beds,
 		Baths:       baths,
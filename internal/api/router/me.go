@@ -1,6 +1,8 @@
 package router
 
 import (
+	"fmt"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 
@@ -109,12 +111,278 @@ func (r *meRouter) logout(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Logout successfully"))
 }
 
+func (r *meRouter) changePassword(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.ChangePasswordRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.ChangePassword(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Change password successfully"))
+}
+
+func (r *meRouter) changeEmail(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.ChangeEmailRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.ChangeEmail(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "A confirmation link has been sent to the new email"))
+}
+
+func (r *meRouter) logoutAll(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	err := r.service.LogoutAll(payload)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Logout from all devices successfully"))
+}
+
+func (r *meRouter) sessions(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.FindSessions(payload)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *meRouter) revokeSession(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	err := r.service.RevokeSession(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Revoke session successfully"))
+}
+
+func (r *meRouter) enrollTwoFactor(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.EnrollTwoFactor(payload)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *meRouter) confirmTwoFactor(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.TwoFactorConfirmRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.ConfirmTwoFactor(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *meRouter) disableTwoFactor(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.TwoFactorDisableRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.DisableTwoFactor(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Two-factor authentication disabled"))
+}
+
+func (r *meRouter) regenerateRecoveryCodes(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.TwoFactorCodeRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.RegenerateRecoveryCodes(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *meRouter) deleteAccount(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.DeleteAccountRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid input"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	err := r.service.DeleteAccount(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Account deleted successfully"))
+}
+
+func (r *meRouter) requestAccountDeletion(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	err := r.service.RequestAccountDeletion(payload)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Account deletion confirmation sent"))
+}
+
+func (r *meRouter) export(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.Export(payload)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	c.Attachment(fmt.Sprintf("booking-export-%d.json", payload.Sub))
+
+	return c.JSON(res)
+}
+
 func MeRouter(router fiber.Router) {
 	routes := newMeRouter()
 
 	router.Get("/me", guard.AuthGuard(), routes.profile)
 	router.Post("/me/avatar", guard.AuthGuard(), routes.uploadAvatar)
 	router.Put("/me", guard.AuthGuard(), routes.updateProfile)
+	router.Delete("/me", guard.AuthGuard(), routes.deleteAccount)
+	router.Post("/me/delete-request", guard.AuthGuard(), routes.requestAccountDeletion)
+	router.Get("/me/export", guard.AuthGuard(), routes.export)
+	router.Put("/me/password", guard.AuthGuard(), routes.changePassword)
+	router.Put("/me/email", guard.AuthGuard(), routes.changeEmail)
 	router.Post("/me/logout", guard.AuthGuard(), routes.logout)
+	router.Post("/me/logout-all", guard.AuthGuard(), routes.logoutAll)
+	router.Get("/me/sessions", guard.AuthGuard(), routes.sessions)
+	router.Delete("/me/sessions/:id", guard.AuthGuard(), routes.revokeSession)
+	router.Post("/me/2fa", guard.AuthGuard(), routes.enrollTwoFactor)
+	router.Post("/me/2fa/confirm", guard.AuthGuard(), routes.confirmTwoFactor)
+	router.Delete("/me/2fa", guard.AuthGuard(), routes.disableTwoFactor)
+	router.Post("/me/2fa/recovery-codes", guard.AuthGuard(), routes.regenerateRecoveryCodes)
 
 }
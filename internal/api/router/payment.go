@@ -0,0 +1,39 @@
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/may20xx/booking/internal/handler"
+	"github.com/may20xx/booking/internal/utils"
+)
+
+type paymentRouter struct {
+	service handler.PaymentService
+}
+
+func newPaymentRouter() *paymentRouter {
+	return &paymentRouter{
+		service: handler.NewPaymentService(),
+	}
+}
+
+func (r *paymentRouter) webhook(c *fiber.Ctx) error {
+	signature := c.Get("X-Payment-Signature")
+
+	if signature == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(utils.NewAppError(401, "Missing signature"))
+	}
+
+	err := r.service.HandleWebhook(c.Body(), signature)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Received"))
+}
+
+func PaymentRouter(router fiber.Router) {
+	routes := newPaymentRouter()
+
+	router.Post("/payments/webhook", routes.webhook)
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/api/middleware/guard"
+	"github.com/may20xx/booking/internal/handler"
+	"github.com/may20xx/booking/internal/utils"
+)
+
+type reviewRouter struct {
+	validate *validator.Validate
+	service  handler.ReviewService
+}
+
+func newReviewRouter() *reviewRouter {
+	return &reviewRouter{
+		validate: validator.New(),
+		service:  handler.NewReviewService(),
+	}
+}
+
+func (r *reviewRouter) save(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.ReviewRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.Save(payload, req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (r *reviewRouter) update(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	req := new(dto.UpdateReviewRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, "Invalid request body!"))
+	}
+
+	if err := r.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.NewAppError(400, err.Error()))
+	}
+
+	res, err := r.service.Update(payload, c.Params("id"), req)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *reviewRouter) unpublish(c *fiber.Ctx) error {
+	payload, ok := c.Locals("user").(*utils.JwtPayload)
+	if !ok || payload == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(401, "Unauthorized"))
+	}
+
+	res, err := r.service.Unpublish(payload, c.Params("id"))
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func (r *reviewRouter) findAllForListing(c *fiber.Ctx) error {
+	id := c.Params("id")
+	page := c.Query("page")
+	limit := c.Query("limit")
+
+	res, err := r.service.FindAllForListing(id, page, limit)
+
+	if err != nil {
+		return c.Status(err.Code).JSON(err)
+	}
+
+	return c.JSON(res)
+}
+
+func ReviewRouter(router fiber.Router) {
+	routes := newReviewRouter()
+
+	router.Post("/reviews", guard.AuthGuard(), routes.save)
+	router.Put("/reviews/:id", guard.AuthGuard(), routes.update)
+	router.Put("/reviews/:id/unpublish", guard.AuthGuard(), routes.unpublish)
+	router.Get("/listings/:id/reviews", routes.findAllForListing)
+}
@@ -28,5 +28,8 @@ func InitRouter(router fiber.Router) {
 		CatalogRouter,
 		ListingRouter,
 		BookingRouter,
+		PaymentRouter,
+		ReviewRouter,
+		AdminRouter,
 	)
 }
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+// jwksMaxAge lets verifiers cache the key set well within the time a retired
+// key is kept around after rotation.
+const jwksMaxAge = "public, max-age=300"
+
+func jwks(c *fiber.Ctx) error {
+	keys, err := utils.GetKeyring()
+
+	if err != nil {
+		log.Msg.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.NewAppError(500, "Internal server error"))
+	}
+
+	c.Set(fiber.HeaderCacheControl, jwksMaxAge)
+
+	return c.JSON(keys.JWKS())
+}
+
+func WellKnownRouter(router fiber.Router) {
+	router.Get("/.well-known/jwks.json", jwks)
+}
@@ -95,4 +95,7 @@ func GracefulShutdown(ctx context.Context) {
 	if err := CloseDatabase(); err != nil {
 		log.Msg.Error("Error closing database connection: ", err)
 	}
+	if err := CloseRedis(); err != nil {
+		log.Msg.Error("Error closing redis connection: ", err)
+	}
 }
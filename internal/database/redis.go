@@ -1 +1,70 @@
 package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/may20xx/booking/config"
+	"github.com/may20xx/booking/pkg/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	rdb       *redis.Client
+	redisOnce sync.Once
+	redisErr  error
+)
+
+// InitRedis connects to the Redis server from the config. An empty address
+// leaves the client unset so callers fall back to in-process state.
+func InitRedis() error {
+	redisOnce.Do(func() {
+		setting := config.GetConfig()
+
+		if setting.RedisAddr == "" {
+			log.Msg.Warn("REDIS_ADDR is empty, keeping revoked tokens in memory")
+			return
+		}
+
+		rdb, redisErr = connectRedisWithRetry(&redis.Options{
+			Addr:     setting.RedisAddr,
+			Password: setting.RedisPassword,
+			DB:       setting.RedisDB,
+		}, maxRetries)
+	})
+	return redisErr
+}
+
+func connectRedisWithRetry(options *redis.Options, maxRetries int) (*redis.Client, error) {
+	client := redis.NewClient(options)
+
+	for i := 0; i < maxRetries; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionTimeout)
+		err := client.Ping(ctx).Err()
+		cancel()
+		if err == nil {
+			log.Msg.Info("Connected to Redis successfully! ✅")
+			return client, nil
+		}
+		log.Msg.Warnf("Failed to connect to redis, retrying (%d/%d)...", i+1, maxRetries)
+		time.Sleep(retryDelay)
+	}
+
+	client.Close()
+	return nil, fmt.Errorf("failed to connect to redis after %d attempts", maxRetries)
+}
+
+// GetRedis returns the shared client, or nil when Redis is not configured.
+func GetRedis() *redis.Client {
+	return rdb
+}
+
+func CloseRedis() error {
+	if rdb != nil {
+		log.Msg.Info("Closing redis connection...")
+		return rdb.Close()
+	}
+	return nil
+}
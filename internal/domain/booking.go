@@ -2,18 +2,54 @@ package domain
 
 import "time"
 
+type BookingStatus string
+
+const (
+	BookingPending   BookingStatus = "pending"
+	BookingConfirmed BookingStatus = "confirmed"
+	BookingDeclined  BookingStatus = "declined"
+	BookingCancelled BookingStatus = "cancelled"
+	BookingCompleted BookingStatus = "completed"
+)
+
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingPending:   {BookingConfirmed, BookingDeclined, BookingCancelled},
+	BookingConfirmed: {BookingCancelled, BookingCompleted},
+}
+
+// CanTransitionTo reports whether a booking in status s may move to next.
+// Declined, cancelled and completed are final.
+func (s BookingStatus) CanTransitionTo(next BookingStatus) bool {
+	for _, status := range bookingTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether a booking in status s holds its nights.
+func (s BookingStatus) IsActive() bool {
+	return s == BookingPending || s == BookingConfirmed
+}
+
 type Booking struct {
-	ID            int       `json:"id" db:"id"`
-	ListingID     int       `json:"-" db:"listing_id"`
-	GuestID       int       `json:"guest_id" db:"guest_id"`
-	Guests        int       `json:"guests" db:"guests"`
-	StartDate     time.Time `json:"start_date" db:"start_date"`
-	EndDate       time.Time `json:"end_date" db:"end_date"`
-	Nights        int       `json:"nights" db:"nights"`
-	PhoneNumber   *string   `json:"phone_number" db:"phone_number"`
-	MessageToHost *string   `json:"message_to_host" db:"message_to_host"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID            int           `json:"id" db:"id"`
+	ListingID     int           `json:"listing_id" db:"listing_id"`
+	GuestID       int           `json:"guest_id" db:"guest_id"`
+	Guests        int           `json:"guests" db:"guests"`
+	StartDate     time.Time     `json:"start_date" db:"start_date"`
+	EndDate       time.Time     `json:"end_date" db:"end_date"`
+	Nights        int           `json:"nights" db:"nights"`
+	PhoneNumber   *string       `json:"phone_number" db:"phone_number"`
+	MessageToHost *string       `json:"message_to_host" db:"message_to_host"`
+	Status        BookingStatus `json:"status" db:"status"`
+	Listing       *Listing      `json:"listing,omitempty" db:"-"`
+	PriceDetail   *PriceDetail  `json:"price_detail,omitempty" db:"-"`
+	Payment       *Payment      `json:"payment,omitempty" db:"-"`
+	Refunds       []*Refund     `json:"refunds,omitempty" db:"-"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
 }
 
 type Payment struct {
@@ -22,7 +58,21 @@ type Payment struct {
 	BookingID    int       `json:"-" db:"booking_id"`
 	IsSuccessful bool      `json:"is_successful" db:"is_successful"`
 	Price        float64   `json:"price" db:"price"`
+	Provider     string    `json:"provider" db:"provider"`
+	ProviderRef  *string   `json:"provider_ref" db:"provider_ref"`
+	Status       string    `json:"status" db:"status"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Refund struct {
+	ID          int       `json:"id" db:"id"`
+	PaymentID   int       `json:"-" db:"payment_id"`
+	BookingID   int       `json:"-" db:"booking_id"`
+	Amount      float64   `json:"amount" db:"amount"`
+	ProviderRef *string   `json:"provider_ref" db:"provider_ref"`
+	Status      string    `json:"status" db:"status"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
 type PriceDetail struct {
@@ -34,4 +84,7 @@ type PriceDetail struct {
 	Taxes          float64   `json:"taxes" db:"taxes"`
 	TotalPrice     float64   `json:"total_price" db:"total_price"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+
+	// Nights itemizes TotalHomePrice night by night.
+	Nights []*NightlyPrice `json:"nights,omitempty" db:"-"`
 }
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingStatus_CanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from BookingStatus
+		to   BookingStatus
+		want bool
+	}{
+		{BookingPending, BookingConfirmed, true},
+		{BookingPending, BookingDeclined, true},
+		{BookingPending, BookingCancelled, true},
+		{BookingPending, BookingCompleted, false},
+		{BookingConfirmed, BookingCancelled, true},
+		{BookingConfirmed, BookingCompleted, true},
+		{BookingConfirmed, BookingDeclined, false},
+		{BookingDeclined, BookingConfirmed, false},
+		{BookingCancelled, BookingPending, false},
+		{BookingCompleted, BookingCancelled, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, c.from.CanTransitionTo(c.to), "%s -> %s", c.from, c.to)
+	}
+}
+
+func TestBookingStatus_IsActive(t *testing.T) {
+	assert.True(t, BookingPending.IsActive())
+	assert.True(t, BookingConfirmed.IsActive())
+	assert.False(t, BookingDeclined.IsActive())
+	assert.False(t, BookingCancelled.IsActive())
+	assert.False(t, BookingCompleted.IsActive())
+}
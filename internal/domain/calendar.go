@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+type DayStatus string
+
+const (
+	DayAvailable DayStatus = "available"
+	DayBooked    DayStatus = "booked"
+	DayBlocked   DayStatus = "blocked"
+)
+
+// BlockedDate is a range of nights a host took off the market, from
+// StartDate up to but not including EndDate.
+type BlockedDate struct {
+	ID        int       `json:"id" db:"id"`
+	ListingID int       `json:"listing_id" db:"listing_id"`
+	StartDate time.Time `json:"start_date" db:"start_date"`
+	EndDate   time.Time `json:"end_date" db:"end_date"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type CalendarDay struct {
+	Date   string    `json:"date"`
+	Status DayStatus `json:"status"`
+}
+
+// Calendar lays out the nights from from up to but not including to, one
+// day at a time. A night held by a booking shows as booked even when the
+// host has also blocked it.
+func Calendar(from time.Time, to time.Time, bookings []*Booking, blocked []*BlockedDate) []*CalendarDay {
+	var days []*CalendarDay
+
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, &CalendarDay{Date: day.Format(time.DateOnly), Status: statusOn(day, bookings, blocked)})
+	}
+
+	return days
+}
+
+func statusOn(day time.Time, bookings []*Booking, blocked []*BlockedDate) DayStatus {
+	for _, booking := range bookings {
+		if booking.Status.IsActive() && holds(booking.StartDate, booking.EndDate, day) {
+			return DayBooked
+		}
+	}
+
+	for _, block := range blocked {
+		if holds(block.StartDate, block.EndDate, day) {
+			return DayBlocked
+		}
+	}
+
+	return DayAvailable
+}
+
+// holds reports whether the night starting on day falls in [start, end).
+func holds(start time.Time, end time.Time, day time.Time) bool {
+	return !day.Before(start) && day.Before(end)
+}
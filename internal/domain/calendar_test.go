@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalendar(t *testing.T) {
+	bookings := []*Booking{
+		{StartDate: date(2, 2), EndDate: date(2, 4), Status: BookingConfirmed},
+		{StartDate: date(2, 5), EndDate: date(2, 6), Status: BookingCancelled},
+	}
+	blocked := []*BlockedDate{
+		{StartDate: date(2, 3), EndDate: date(2, 6)},
+	}
+
+	days := Calendar(date(2, 1), date(2, 7), bookings, blocked)
+
+	want := []*CalendarDay{
+		{Date: "2025-02-01", Status: DayAvailable},
+		{Date: "2025-02-02", Status: DayBooked},
+		{Date: "2025-02-03", Status: DayBooked},
+		{Date: "2025-02-04", Status: DayBlocked},
+		{Date: "2025-02-05", Status: DayBlocked},
+		{Date: "2025-02-06", Status: DayAvailable},
+	}
+
+	assert.Equal(t, want, days)
+}
+
+func TestCalendarAcrossMonths(t *testing.T) {
+	days := Calendar(date(1, 1), date(3, 1), nil, nil)
+
+	assert.Len(t, days, 59)
+	assert.Equal(t, "2025-01-31", days[30].Date)
+	assert.Equal(t, "2025-02-28", days[58].Date)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func (p GeoPoint) Valid() bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// DistanceKm is the great-circle distance between p and q.
+func (p GeoPoint) DistanceKm(q GeoPoint) float64 {
+	lat1, lat2 := radians(p.Latitude), radians(q.Latitude)
+	dLat := lat2 - lat1
+	dLng := radians(q.Longitude - p.Longitude)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// BoundingBox is the area shown on a map. West is greater than East when the
+// box crosses the antimeridian.
+type BoundingBox struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
+}
+
+func (b BoundingBox) Valid() bool {
+	southWest := GeoPoint{Latitude: b.South, Longitude: b.West}
+	northEast := GeoPoint{Latitude: b.North, Longitude: b.East}
+
+	return southWest.Valid() && northEast.Valid() && b.South <= b.North
+}
+
+// Around is the smallest box holding every point within radiusKm of p. It is
+// used to narrow a radius search down with an index before measuring.
+func (p GeoPoint) Around(radiusKm float64) BoundingBox {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+
+	box := BoundingBox{
+		South: math.Max(-90, p.Latitude-dLat),
+		North: math.Min(90, p.Latitude+dLat),
+		West:  -180,
+		East:  180,
+	}
+
+	// Near the poles every longitude is within reach.
+	if box.South == -90 || box.North == 90 {
+		return box
+	}
+
+	dLng := math.Asin(math.Sin(radians(dLat))/math.Cos(radians(p.Latitude))) * 180 / math.Pi
+
+	box.West = wrapLongitude(p.Longitude - dLng)
+	box.East = wrapLongitude(p.Longitude + dLng)
+
+	return box
+}
+
+func wrapLongitude(lng float64) float64 {
+	if lng < -180 {
+		return lng + 360
+	}
+	if lng > 180 {
+		return lng - 360
+	}
+	return lng
+}
+
+// MaxClusterZoom is the deepest map zoom level pins are clustered for.
+const MaxClusterZoom = 20
+
+// clustersPerTile is how many cluster cells fit across a 256 pixel map tile,
+// making each cell 32 pixels wide whatever the zoom.
+const clustersPerTile = 8
+
+// ClusterCellSize is the width in degrees of the grid cells pins are grouped
+// in at a map zoom level.
+func ClusterCellSize(zoom int) float64 {
+	if zoom < 0 {
+		zoom = 0
+	}
+	if zoom > MaxClusterZoom {
+		zoom = MaxClusterZoom
+	}
+	return 360 / math.Pow(2, float64(zoom)) / clustersPerTile
+}
+
+// Cluster is a group of listings shown as one pin on a map. ListingID is set
+// when the cluster holds a single listing.
+type Cluster struct {
+	Latitude  float64 `json:"latitude" db:"latitude"`
+	Longitude float64 `json:"longitude" db:"longitude"`
+	Count     int     `json:"count" db:"count"`
+	ListingID *int    `json:"listing_id,omitempty" db:"listing_id"`
+}
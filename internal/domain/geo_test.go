@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	hanoi = GeoPoint{Latitude: 21.0285, Longitude: 105.8542}
+	hcmc  = GeoPoint{Latitude: 10.8231, Longitude: 106.6297}
+)
+
+func TestGeoPoint_DistanceKm(t *testing.T) {
+	assert.InDelta(t, 1138, hanoi.DistanceKm(hcmc), 5)
+	assert.InDelta(t, hanoi.DistanceKm(hcmc), hcmc.DistanceKm(hanoi), 1e-9)
+	assert.Zero(t, hanoi.DistanceKm(hanoi))
+}
+
+func TestGeoPoint_Around(t *testing.T) {
+	box := hanoi.Around(10)
+
+	assert.True(t, box.Valid())
+	assert.Less(t, box.West, hanoi.Longitude)
+	assert.Greater(t, box.East, hanoi.Longitude)
+
+	for _, p := range []GeoPoint{
+		{Latitude: box.North, Longitude: hanoi.Longitude},
+		{Latitude: box.South, Longitude: hanoi.Longitude},
+	} {
+		assert.InDelta(t, 10, hanoi.DistanceKm(p), 0.01)
+	}
+
+	fiji := GeoPoint{Latitude: -17.7, Longitude: 179.9}.Around(50)
+	assert.Greater(t, fiji.West, fiji.East, "box should cross the antimeridian")
+
+	pole := GeoPoint{Latitude: 89.9, Longitude: 0}.Around(50)
+	assert.Equal(t, -180.0, pole.West)
+	assert.Equal(t, 180.0, pole.East)
+}
+
+func TestClusterCellSize(t *testing.T) {
+	assert.Equal(t, 45.0, ClusterCellSize(0))
+	assert.Equal(t, 22.5, ClusterCellSize(1))
+	assert.Equal(t, ClusterCellSize(MaxClusterZoom), ClusterCellSize(30))
+	assert.Equal(t, ClusterCellSize(0), ClusterCellSize(-1))
+}
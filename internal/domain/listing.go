@@ -8,6 +8,8 @@ type Listing struct {
 	Title       string     `json:"title" db:"title"`
 	Description string     `json:"description" db:"description"`
 	Location    string     `json:"location" db:"location"`
+	Latitude    *float64   `json:"latitude" db:"latitude"`
+	Longitude   *float64   `json:"longitude" db:"longitude"`
 	Guests      int        `json:"guests" db:"guests"`
 	Beds        int        `json:"beds" db:"beds"`
 	Baths       int        `json:"baths" db:"baths"`
@@ -19,6 +21,22 @@ type Listing struct {
 	Landlord    *Landlord  `json:"landlord" db:"-"`
 	Photos      []*Photo   `json:"photos" db:"-"`
 	Review      []*Review  `json:"reviews,omitempty" db:"-"`
+	Rating      float64    `json:"rating" db:"-"`
+	ReviewCount int        `json:"review_count" db:"-"`
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty" db:"-"`
+	PriceRules         []*PriceRule        `json:"price_rules,omitempty" db:"-"`
+
+	// ArchivedAt is set when the landlord deleted their account. Archived
+	// listings stay for the bookings made on them but cannot be booked.
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+
+	// Snippet is the part of the description that matched a text search,
+	// with the matches wrapped in <mark> tags.
+	Snippet *string `json:"snippet,omitempty" db:"-"`
+	// Distance is how far the listing is from the point searched around, in
+	// kilometres.
+	Distance *float64 `json:"distance_km,omitempty" db:"-"`
 
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
@@ -34,6 +52,16 @@ type Landlord struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// Author is the public view of someone who wrote a review. Unlike Landlord
+// it carries no contact details.
+type Author struct {
+	ID        int     `json:"id" db:"id"`
+	Username  string  `json:"username" db:"username"`
+	FirstName string  `json:"full_name" db:"first_name"`
+	Surname   string  `json:"surname" db:"surname"`
+	Avatar    *string `json:"avatar" db:"avatar"`
+}
+
 type Photo struct {
 	ID        int       `json:"id" db:"id"`
 	ListingID int       `json:"-" db:"listing_id"`
@@ -51,6 +79,81 @@ type Review struct {
 	Comment     string    `json:"comment" db:"comment"`
 	IsPublished bool      `json:"is_published" db:"is_published"`
 	IsEdited    bool      `json:"is_edited" db:"is_edited"`
+	Author      *Author   `json:"author,omitempty" db:"-"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
+
+const (
+	PolicyFlexible = "flexible"
+	PolicyModerate = "moderate"
+	PolicyStrict   = "strict"
+)
+
+// CancellationPolicy decides how much of a stay is refunded: cancelling at
+// least CutoffDays before check-in refunds RefundPercent of the total,
+// cancelling later refunds LateRefundPercent.
+type CancellationPolicy struct {
+	ListingID         int       `json:"-" db:"listing_id"`
+	Name              string    `json:"name" db:"name"`
+	CutoffDays        int       `json:"cutoff_days" db:"cutoff_days"`
+	RefundPercent     int       `json:"refund_percent" db:"refund_percent"`
+	LateRefundPercent int       `json:"late_refund_percent" db:"late_refund_percent"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+var defaultCancellationPolicies = map[string]CancellationPolicy{
+	PolicyFlexible: {Name: PolicyFlexible, CutoffDays: 1, RefundPercent: 100, LateRefundPercent: 0},
+	PolicyModerate: {Name: PolicyModerate, CutoffDays: 5, RefundPercent: 100, LateRefundPercent: 50},
+	PolicyStrict:   {Name: PolicyStrict, CutoffDays: 14, RefundPercent: 50, LateRefundPercent: 0},
+}
+
+// DefaultCancellationPolicy returns the preset terms of the named policy.
+func DefaultCancellationPolicy(name string) (*CancellationPolicy, bool) {
+	policy, ok := defaultCancellationPolicies[name]
+	if !ok {
+		return nil, false
+	}
+	return &policy, true
+}
+
+type ListingSort string
+
+const (
+	SortNewest    ListingSort = "newest"
+	SortPriceAsc  ListingSort = "price_asc"
+	SortPriceDesc ListingSort = "price_desc"
+	SortRating    ListingSort = "rating"
+	SortRelevance ListingSort = "relevance"
+	SortDistance  ListingSort = "distance"
+)
+
+// ListingSearch holds the filters of a listing search. Zero values leave a
+// filter out. Text is matched against the title, location and description.
+// When CheckIn and CheckOut are set, only listings free for the whole stay
+// match. Near and RadiusKm, or Bounds, keep listings within an area.
+type ListingSearch struct {
+	Text       string
+	Location   string
+	MinPrice   *float64
+	MaxPrice   *float64
+	Guests     int
+	Beds       int
+	Baths      int
+	CatalogIDs []int
+	CheckIn    *time.Time
+	CheckOut   *time.Time
+	Near       *GeoPoint
+	RadiusKm   float64
+	Bounds     *BoundingBox
+	Sort       ListingSort
+}
+
+// Suggestion completes what a user is typing into the search box, with either
+// the title of a listing or a location.
+type Suggestion struct {
+	Kind      string `json:"kind" db:"kind"`
+	Text      string `json:"text" db:"text"`
+	ListingID *int   `json:"listing_id,omitempty" db:"listing_id"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+// LoginAttempts counts the recent failed sign-ins for an account or a client
+// address.
+type LoginAttempts struct {
+	Failures     int
+	LastFailedAt time.Time
+}
+
+// LoginThrottle slows down repeated failed sign-ins. The first FreeAttempts
+// failures cost nothing, every later one doubles the wait starting from
+// BaseDelay up to MaxDelay, and LockoutAfter failures block sign-in for
+// LockoutFor. Failures are forgotten LockoutFor after the last one.
+type LoginThrottle struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockoutAfter int
+	LockoutFor   time.Duration
+}
+
+var (
+	AccountLoginThrottle = LoginThrottle{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+	}
+
+	// Many users can share an address, so it gets more headroom.
+	IPLoginThrottle = LoginThrottle{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 50,
+		LockoutFor:   15 * time.Minute,
+	}
+)
+
+// RetryAt returns when the next attempt is allowed, or the zero time when it
+// is allowed right away.
+func (t LoginThrottle) RetryAt(attempts *LoginAttempts) time.Time {
+	if attempts == nil || attempts.Failures < t.FreeAttempts {
+		return time.Time{}
+	}
+
+	if t.Locks(attempts) {
+		return attempts.LastFailedAt.Add(t.LockoutFor)
+	}
+
+	delay := t.MaxDelay
+
+	if shift := attempts.Failures - t.FreeAttempts; shift < 32 {
+		delay = min(t.BaseDelay<<shift, t.MaxDelay)
+	}
+
+	return attempts.LastFailedAt.Add(delay)
+}
+
+// Locks reports whether the failures are enough to lock sign-in out.
+func (t LoginThrottle) Locks(attempts *LoginAttempts) bool {
+	return attempts != nil && attempts.Failures >= t.LockoutAfter
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottle_RetryAt(t *testing.T) {
+	throttle := LoginThrottle{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     10 * time.Second,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+	}
+
+	last := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		failures int
+		want     time.Time
+	}{
+		{0, time.Time{}},
+		{2, time.Time{}},
+		{3, last.Add(time.Second)},
+		{4, last.Add(2 * time.Second)},
+		{6, last.Add(8 * time.Second)},
+		{7, last.Add(10 * time.Second)},
+		{9, last.Add(10 * time.Second)},
+		{10, last.Add(15 * time.Minute)},
+		{100, last.Add(15 * time.Minute)},
+	}
+
+	for _, c := range cases {
+		attempts := &LoginAttempts{Failures: c.failures, LastFailedAt: last}
+		assert.Equal(t, c.want, throttle.RetryAt(attempts), "failures %d", c.failures)
+	}
+
+	assert.True(t, throttle.RetryAt(nil).IsZero())
+}
+
+func TestLoginThrottle_Locks(t *testing.T) {
+	assert.False(t, AccountLoginThrottle.Locks(nil))
+	assert.False(t, AccountLoginThrottle.Locks(&LoginAttempts{Failures: 9}))
+	assert.True(t, AccountLoginThrottle.Locks(&LoginAttempts{Failures: 10}))
+	assert.False(t, IPLoginThrottle.Locks(&LoginAttempts{Failures: 10}))
+}
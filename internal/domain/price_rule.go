@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+type PriceRuleKind string
+
+const (
+	RuleWeekend PriceRuleKind = "weekend"
+	RuleSeason  PriceRuleKind = "season"
+	RuleWeekly  PriceRuleKind = "weekly"
+	RuleMonthly PriceRuleKind = "monthly"
+)
+
+const (
+	WeeklyStayNights  = 7
+	MonthlyStayNights = 28
+)
+
+// PriceRule changes a listing's nightly price. A weekend rule adds Percent
+// to Friday and Saturday nights, a season rule charges Price a night from
+// StartDate up to but not including EndDate, and weekly and monthly rules
+// take Percent off every night of stays of at least WeeklyStayNights or
+// MonthlyStayNights.
+type PriceRule struct {
+	ID        int           `json:"id" db:"id"`
+	ListingID int           `json:"-" db:"listing_id"`
+	Kind      PriceRuleKind `json:"kind" db:"kind"`
+	Percent   *float64      `json:"percent,omitempty" db:"percent"`
+	Price     *float64      `json:"price,omitempty" db:"price"`
+	StartDate *time.Time    `json:"start_date,omitempty" db:"start_date"`
+	EndDate   *time.Time    `json:"end_date,omitempty" db:"end_date"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// NightlyPrice is what one night of a stay costs: the listing's or the
+// season's BasePrice, plus WeekendSurcharge, less the length-of-stay
+// Discount.
+type NightlyPrice struct {
+	ID               int       `json:"-" db:"id"`
+	PriceDetailID    int       `json:"-" db:"price_detail_id"`
+	Date             time.Time `json:"date" db:"night"`
+	BasePrice        float64   `json:"base_price" db:"base_price"`
+	WeekendSurcharge float64   `json:"weekend_surcharge" db:"weekend_surcharge"`
+	Discount         float64   `json:"discount" db:"discount"`
+	Price            float64   `json:"price" db:"price"`
+}
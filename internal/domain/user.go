@@ -16,6 +16,34 @@ type User struct {
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// TwoFactor is the TOTP state of an account. Secret is set from enrollment
+// on, but codes are only required once Enabled. LastCounter is the period of
+// the last accepted code, so that a code cannot be used twice.
+type TwoFactor struct {
+	UserID      int     `db:"id"`
+	Secret      *string `db:"totp_secret"`
+	Enabled     bool    `db:"totp_enabled"`
+	LastCounter int64   `db:"totp_last_counter"`
+}
+
+// UserIdentity links an account at an external identity provider to a user.
+type UserIdentity struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"-" db:"user_id"`
+	Provider  string    `json:"provider" db:"provider"`
+	Subject   string    `json:"-" db:"subject"`
+	Email     *string   `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// OIDCState is what an external sign-in needs to remember between sending
+// the user to the provider and the provider sending them back.
+type OIDCState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
 type Role struct {
 	ID          int       `json:"id" db:"id"`
 	RoleName    string    `json:"name" db:"role_name"`
@@ -37,4 +65,19 @@ type Token struct {
 	CreatedAt time.Time  `db:"created_at"`
 	UpdatedAt time.Time  `db:"updated_at"`
 	ExpiredAt *time.Time `db:"expired_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	SessionID *int       `db:"session_id"`
+}
+
+// Session is a signed-in device. Each session owns one chain of rotated
+// refresh tokens.
+type Session struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IP         string     `json:"ip" db:"ip"`
+	Current    bool       `json:"current" db:"-"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
 }
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+// DeleteAccount erases the user's personal data and signs them out
+// everywhere. Their bookings, payments and reviews stay under an anonymous
+// name, because the hosts and guests on the other side still depend on them.
+func (s *meService) DeleteAccount(payload *utils.JwtPayload, req *dto.DeleteAccountRequest) *utils.AppError {
+	user, ext := s.findForDeletion(payload, req)
+
+	if ext != nil {
+		return ext
+	}
+
+	active, err := s.bookingRepo.ExistActiveForUser(user.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	if active {
+		return utils.NewAppError(409, "Cancel or complete your upcoming bookings before deleting your account")
+	}
+
+	twoFactor, ext := s.findTwoFactor(user.ID)
+
+	if ext != nil {
+		return ext
+	}
+
+	if twoFactor.Enabled {
+		ok, ext := verifySecondFactor(s.userRepo, s.tokenRepo, twoFactor, req.Code)
+
+		if ext != nil {
+			return ext
+		}
+
+		if !ok {
+			return utils.NewAppError(400, "Invalid code")
+		}
+	}
+
+	if req.Password == "" {
+		if _, ext := consumeToken(s.tokenRepo, req.Token, dto.AccountDeleteToken); ext != nil {
+			return ext
+		}
+	}
+
+	if err := s.userRepo.Anonymize(user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewAppError(404, "User not found")
+		}
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Error deleting account")
+	}
+
+	return revokeUserAccessTokens(s.revocations, user.ID)
+}
+
+// RequestAccountDeletion mails a token that confirms DeleteAccount in place
+// of the password. It is meant for accounts signed up through an identity
+// provider, whose password was generated and never known to the user.
+func (s *meService) RequestAccountDeletion(payload *utils.JwtPayload) *utils.AppError {
+	user, err := s.userRepo.FindOneById(payload.Sub)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(404, "User not found")
+	}
+
+	identities, err := s.identityRepo.FindAllForUser(user.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	if len(identities) == 0 {
+		return utils.NewAppError(400, "Confirm the deletion with your password")
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(user.ID, dto.AccountDeleteToken); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	token, ext := issueToken(s.tokenRepo, user, dto.AccountDeleteToken, verificationTokenTTL)
+
+	if ext != nil {
+		return ext
+	}
+
+	if err := s.mail.SendMailConfirmAccountDeletion(user.Email, token); err != nil {
+		return utils.NewAppError(500, "Mail send failed")
+	}
+
+	return nil
+}
+
+// findForDeletion checks the password, or when none is given, that the
+// mailed deletion token belongs to the user. The token is only used up once
+// the account is actually deleted.
+func (s *meService) findForDeletion(payload *utils.JwtPayload, req *dto.DeleteAccountRequest) (*domain.User, *utils.AppError) {
+	if req.Password != "" {
+		return s.findWithPassword(payload, req.Password)
+	}
+
+	token, ext := findToken(s.tokenRepo, req.Token, dto.AccountDeleteToken)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if token.UserID != payload.Sub {
+		return nil, utils.NewAppError(404, "Token not found")
+	}
+
+	user, err := s.userRepo.FindOneById(payload.Sub)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(404, "User not found")
+	}
+
+	return user, nil
+}
+
+// Export gathers the user's personal data: the profile, linked identities,
+// sessions, listings with their photos, bookings with what was paid for them,
+// and the reviews they wrote.
+func (s *meService) Export(payload *utils.JwtPayload) (*dto.PersonalDataExport, *utils.AppError) {
+	profile, ext := s.GetProfile(payload)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	identities, err := s.identityRepo.FindAllForUser(profile.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	sessions, err := s.sessionRepo.FindAllForUser(profile.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	listings, ext := s.exportListings(profile.ID)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	bookings, ext := s.exportBookings(profile.ID)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	reviews, err := s.reviewRepo.FindAllForAuthor(profile.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	exported := make([]*dto.ExportedReview, 0, len(reviews))
+
+	for _, review := range reviews {
+		exported = append(exported, &dto.ExportedReview{
+			ListingID: review.ListingID,
+			BookingID: review.BookingID,
+			Review:    review,
+		})
+	}
+
+	return &dto.PersonalDataExport{
+		ExportedAt: time.Now(),
+		Profile:    profile,
+		Identities: identities,
+		Sessions:   sessions,
+		Listings:   listings,
+		Bookings:   bookings,
+		Reviews:    exported,
+	}, nil
+}
+
+func (s *meService) exportListings(userId int) ([]*domain.Listing, *utils.AppError) {
+	listings, err := s.listingRepo.FindAllForLandlord(userId)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	for _, listing := range listings {
+		photos, err := s.photoRepo.FindAllForListing(listing.ID)
+
+		if err != nil {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Internal server error")
+		}
+
+		listing.Photos = photos
+	}
+
+	return listings, nil
+}
+
+func (s *meService) exportBookings(userId int) ([]*domain.Booking, *utils.AppError) {
+	bookings, err := s.bookingRepo.FindAllForGuest(userId)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	for _, booking := range bookings {
+		priceDetail, err := s.bookingRepo.FindPriceDetail(booking.ID)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Internal server error")
+		}
+
+		charge, err := s.paymentRepo.FindForBooking(booking.ID)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Internal server error")
+		}
+
+		refunds, err := s.paymentRepo.FindRefundsForBooking(booking.ID)
+
+		if err != nil {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Internal server error")
+		}
+
+		booking.PriceDetail = priceDetail
+		booking.Payment = charge
+		booking.Refunds = refunds
+	}
+
+	return bookings, nil
+}
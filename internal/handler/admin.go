@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+	"github.com/samber/lo"
+)
+
+type AdminService interface {
+	FindRoles() (*utils.Response, *utils.AppError)
+	FindUserRoles(userId string) (*utils.Response, *utils.AppError)
+	GrantRole(userId string, req *dto.RoleRequest) (*utils.Response, *utils.AppError)
+	RevokeRole(payload *utils.JwtPayload, userId string, role string) (*utils.Response, *utils.AppError)
+}
+
+type adminService struct {
+	userRepo    storage.UserRepository
+	roleRepo    storage.RoleStorage
+	revocations storage.RevocationStore
+}
+
+func NewAdminService() AdminService {
+	ctx := context.Background()
+
+	db, err := database.GetDatabase(ctx)
+	if err != nil {
+		log.Msg.Panic("error getting database connection %s", err)
+	}
+
+	return &adminService{
+		userRepo:    storage.NewUserRepository(db, ctx),
+		roleRepo:    storage.NewRoleRepository(db, ctx),
+		revocations: Revocations(),
+	}
+}
+
+func (s *adminService) FindRoles() (*utils.Response, *utils.AppError) {
+	roles, err := s.roleRepo.FindAll()
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, roles), nil
+}
+
+func (s *adminService) FindUserRoles(userId string) (*utils.Response, *utils.AppError) {
+	user, roles, ext := s.findUserRoles(userId)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	return utils.NewResponse(200, &dto.UserRolesResponse{User: user, Roles: roles}), nil
+}
+
+func (s *adminService) GrantRole(userId string, req *dto.RoleRequest) (*utils.Response, *utils.AppError) {
+	user, roles, ext := s.findUserRoles(userId)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if hasRole(roles, req.Role) {
+		return nil, utils.NewAppError(409, "User already has this role!")
+	}
+
+	role, ext := s.findRole(req.Role)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	err := s.roleRepo.InsertRoleToUser(&domain.UserRole{UserID: user.ID, RoleID: role.ID})
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	roles = append(roles, *role)
+
+	return utils.NewResponse(200, &dto.UserRolesResponse{User: user, Roles: roles}), nil
+}
+
+// RevokeRole removes a role from a user. Admins cannot revoke their own admin
+// role so that at least one admin is always left to grant it back. Access
+// tokens still carrying the role are revoked so it stops applying at once.
+func (s *adminService) RevokeRole(payload *utils.JwtPayload, userId string, role string) (*utils.Response, *utils.AppError) {
+	user, roles, ext := s.findUserRoles(userId)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if user.ID == payload.Sub && role == dto.Admin {
+		return nil, utils.NewAppError(400, "You cannot revoke your own admin role!")
+	}
+
+	if !hasRole(roles, role) {
+		return nil, utils.NewAppError(404, "User does not have this role!")
+	}
+
+	existingRole, ext := s.findRole(role)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	err := s.roleRepo.RemoveRoleFromUser(&domain.UserRole{UserID: user.ID, RoleID: existingRole.ID})
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if ext := revokeUserAccessTokens(s.revocations, user.ID); ext != nil {
+		return nil, ext
+	}
+
+	roles = lo.Reject(roles, func(r domain.Role, _ int) bool {
+		return r.RoleName == role
+	})
+
+	return utils.NewResponse(200, &dto.UserRolesResponse{User: user, Roles: roles}), nil
+}
+
+func (s *adminService) findUserRoles(userId string) (*domain.Landlord, []domain.Role, *utils.AppError) {
+	userIdInt, err := strconv.Atoi(userId)
+
+	if err != nil {
+		return nil, nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	user, err := s.userRepo.FindLandlord(userIdInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, utils.NewAppError(404, "User not found!")
+		}
+		log.Msg.Error(err)
+		return nil, nil, utils.NewAppError(500, err.Error())
+	}
+
+	roles, err := s.roleRepo.FindRolesByUser(user.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, nil, utils.NewAppError(500, err.Error())
+	}
+
+	return user, roles, nil
+}
+
+func (s *adminService) findRole(name string) (*domain.Role, *utils.AppError) {
+	role, err := s.roleRepo.FindRoleByName(name)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Role not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return role, nil
+}
+
+func hasRole(roles []domain.Role, name string) bool {
+	return lo.ContainsBy(roles, func(role domain.Role) bool {
+		return role.RoleName == name
+	})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/may20xx/booking/internal/api/dto"
@@ -12,20 +13,51 @@ import (
 	"github.com/may20xx/booking/internal/utils"
 	"github.com/may20xx/booking/pkg/log"
 	"github.com/may20xx/booking/pkg/mail"
+	"github.com/may20xx/booking/pkg/oidc"
+	"github.com/may20xx/booking/pkg/secret"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	verificationTokenTTL  = 24 * time.Hour
+	passwordResetTokenTTL = time.Hour
+	unlockTokenTTL        = time.Hour
+
+	// At most maxVerificationsPerWindow verification or password reset
+	// emails are sent to an account within verificationWindow.
+	verificationWindow        = time.Hour
+	maxVerificationsPerWindow = 3
+)
+
+// dummyPasswordHash is compared against when the username does not exist.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("booking-dummy-password"), bcrypt.DefaultCost)
+
 type AuthService interface {
 	RegisterHandler(request *dto.RegisterRequest) (*domain.User, *utils.AppError)
-	LoginHandler(request *dto.LoginRequest) (*utils.TokenResponse, *utils.AppError)
+	LoginHandler(request *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, *utils.AppError)
+	VerifyTwoFactorHandler(request *dto.TwoFactorVerifyRequest, client *dto.ClientInfo) (*utils.TokenResponse, *utils.AppError)
+	RefreshHandler(request *dto.RefreshRequest, client *dto.ClientInfo) (*utils.TokenResponse, *utils.AppError)
 	VerifyEmailHandler(token string) *utils.AppError
+	ResendVerificationHandler(request *dto.ResendVerificationRequest) *utils.AppError
+	ForgotPasswordHandler(request *dto.ForgotPasswordRequest) *utils.AppError
+	ResetPasswordHandler(request *dto.ResetPasswordRequest) *utils.AppError
+	ConfirmEmailChangeHandler(token string) *utils.AppError
+	UnlockAccountHandler(token string) *utils.AppError
+	OIDCAuthURLHandler(provider string) (string, *utils.AppError)
+	OIDCCallbackHandler(provider string, code string, state string, client *dto.ClientInfo) (*dto.LoginResponse, *utils.AppError)
 }
 
 type authService struct {
-	userRepo  storage.UserRepository
-	tokenRepo storage.TokenStorage
-	roleRepo  storage.RoleStorage
-	mail      mail.Mail
+	userRepo      storage.UserRepository
+	tokenRepo     storage.TokenStorage
+	roleRepo      storage.RoleStorage
+	sessionRepo   storage.SessionRepository
+	identityRepo  storage.IdentityRepository
+	revocations   storage.RevocationStore
+	loginAttempts storage.LoginAttemptStore
+	oidcStates    storage.OIDCStateStore
+	providers     map[string]oidc.Provider
+	mail          mail.Mail
 }
 
 func NewAuthService() *authService {
@@ -37,10 +69,16 @@ func NewAuthService() *authService {
 	}
 
 	return &authService{
-		userRepo:  storage.NewUserRepository(db, ctx),
-		tokenRepo: storage.NewTokenRepository(db, ctx),
-		roleRepo:  storage.NewRoleRepository(db, ctx),
-		mail:      mail.NewMailService(),
+		userRepo:      storage.NewUserRepository(db, ctx),
+		tokenRepo:     storage.NewTokenRepository(db, ctx),
+		roleRepo:      storage.NewRoleRepository(db, ctx),
+		sessionRepo:   storage.NewSessionRepository(db, ctx),
+		identityRepo:  storage.NewIdentityRepository(db, ctx),
+		revocations:   Revocations(),
+		loginAttempts: LoginAttempts(),
+		oidcStates:    OIDCStates(),
+		providers:     newOIDCProviders(),
+		mail:          mail.NewMailService(),
 	}
 }
 
@@ -83,95 +121,210 @@ func (s *authService) RegisterHandler(request *dto.RegisterRequest) (*domain.Use
 		return nil, utils.NewAppError(500, err.Error())
 	}
 
-	token, err := utils.GenerateJWT(result)
-
-	if err != nil {
-		return nil, utils.NewAppError(400, "Error generating JWT")
+	if ext := s.sendVerification(result); ext != nil {
+		return nil, ext
 	}
 
-	err = s.mail.SendMailConfirmAccount(result.Email, token.AccessToken)
+	return result, nil
+}
 
-	if err != nil {
-		return nil, utils.NewAppError(500, "Mail send failed")
+func (s *authService) VerifyEmailHandler(token string) *utils.AppError {
+	existingToken, ext := consumeToken(s.tokenRepo, token, dto.ConfirmAccessToken)
+
+	if ext != nil {
+		return ext
 	}
 
-	expirationTime := time.Now().Add(7 * 24 * time.Hour)
+	account, err := s.userRepo.FindOneById(existingToken.UserID)
 
-	verifyToken := &domain.Token{
-		UserID:    user.ID,
-		Name:      dto.RefreshToken,
-		Token:     token.AccessToken,
-		ExpiredAt: &expirationTime,
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(404, "User not found")
 	}
 
-	_, err = s.tokenRepo.Insert(verifyToken)
+	account.EmailVerify = true
+
+	_, err = s.userRepo.VerifyEmail(account)
 
 	if err != nil {
-		return nil, utils.NewAppError(500, "Error saving refresh token")
+		return utils.NewAppError(500, "Error updating account")
 	}
 
-	return result, nil
+	return nil
 }
 
-func (s *authService) VerifyEmailHandler(token string) *utils.AppError {
+// ResendVerificationHandler mails a fresh verification link and invalidates
+// the previous ones. It answers the same way whether or not the email
+// belongs to an unverified account, and whether or not the account has hit
+// the resend limit.
+func (s *authService) ResendVerificationHandler(request *dto.ResendVerificationRequest) *utils.AppError {
+	user, err := s.userRepo.FindOneByEmail(request.Email)
+
+	if err != nil || user.EmailVerify {
+		return nil
+	}
 
-	existingToken, err := s.tokenRepo.FindOneByValue(token)
+	sent, err := s.tokenRepo.CountSince(user.ID, dto.ConfirmAccessToken, time.Now().Add(-verificationWindow))
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return utils.NewAppError(404, "Token not found")
-		}
+		log.Msg.Error(err)
 		return utils.NewAppError(500, "Internal server error")
 	}
 
-	if existingToken.ExpiredAt != nil && existingToken.ExpiredAt.Before(time.Now()) {
-		return utils.NewAppError(401, "Token expired")
+	if sent >= maxVerificationsPerWindow {
+		log.Msg.Warnf("Skipping verification email for user %d: too many sent", user.ID)
+		return nil
 	}
 
-	payload, err := utils.ValidateJWT(existingToken.Token)
+	if err := s.tokenRepo.RevokeAllForUser(user.ID, dto.ConfirmAccessToken); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	// A failure is only logged: answering differently would tell that the
+	// account exists.
+	if ext := s.sendVerification(user); ext != nil {
+		log.Msg.Errorf("error sending verification email to user %d: %s", user.ID, ext.Message)
+	}
+
+	return nil
+}
+
+func (s *authService) sendVerification(user *domain.User) *utils.AppError {
+	token, ext := issueToken(s.tokenRepo, user, dto.ConfirmAccessToken, verificationTokenTTL)
+
+	if ext != nil {
+		return ext
+	}
+
+	if err := s.mail.SendMailConfirmAccount(user.Email, token); err != nil {
+		return utils.NewAppError(500, "Mail send failed")
+	}
+
+	return nil
+}
+
+// ForgotPasswordHandler mails a password reset link. Like the verification
+// resend it does not reveal whether the email is registered, so a throttled
+// request looks like any other.
+func (s *authService) ForgotPasswordHandler(request *dto.ForgotPasswordRequest) *utils.AppError {
+	user, err := s.userRepo.FindOneByEmail(request.Email)
 
 	if err != nil {
-		return utils.NewAppError(401, "Invalid token")
+		return nil
 	}
 
-	account, err := s.userRepo.FindOneById(payload.Sub)
+	sent, err := s.tokenRepo.CountSince(user.ID, dto.PasswordResetToken, time.Now().Add(-verificationWindow))
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return utils.NewAppError(404, "User not found")
-		}
+		log.Msg.Error(err)
 		return utils.NewAppError(500, "Internal server error")
 	}
 
-	account.EmailVerify = true
+	if sent >= maxVerificationsPerWindow {
+		log.Msg.Warnf("Skipping password reset email for user %d: too many sent", user.ID)
+		return nil
+	}
 
-	_, err = s.userRepo.VerifyEmail(account)
+	if err := s.tokenRepo.RevokeAllForUser(user.ID, dto.PasswordResetToken); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	token, ext := issueToken(s.tokenRepo, user, dto.PasswordResetToken, passwordResetTokenTTL)
+
+	if ext != nil {
+		return ext
+	}
+
+	// A failure is only logged: answering differently would tell that the
+	// account exists.
+	if err := s.mail.SendMailResetPassword(user.Email, token); err != nil {
+		log.Msg.Errorf("error sending password reset email to user %d: %s", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPasswordHandler sets a new password with a reset token and signs the
+// user out of every session.
+func (s *authService) ResetPasswordHandler(request *dto.ResetPasswordRequest) *utils.AppError {
+	existingToken, ext := consumeToken(s.tokenRepo, request.Token, dto.PasswordResetToken)
+
+	if ext != nil {
+		return ext
+	}
+
+	user, err := s.userRepo.FindOneById(existingToken.UserID)
 
 	if err != nil {
-		return utils.NewAppError(500, "Error updating account")
+		log.Msg.Error(err)
+		return utils.NewAppError(404, "User not found")
 	}
 
-	err = s.tokenRepo.Remove(existingToken.ID)
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 
 	if err != nil {
-		return utils.NewAppError(500, "Error removing token")
+		return utils.NewAppError(500, err.Error())
+	}
+
+	user.HashPassword = string(hash)
+
+	if _, err := s.userRepo.Update(user); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Error updating account")
+	}
+
+	return revokeSessions(s.sessionRepo, s.tokenRepo, s.revocations, user.ID, 0)
+}
+
+// ConfirmEmailChangeHandler switches the account to its pending email once
+// the link mailed to the new address is opened.
+func (s *authService) ConfirmEmailChangeHandler(token string) *utils.AppError {
+	existingToken, ext := consumeToken(s.tokenRepo, token, dto.EmailChangeToken)
+
+	if ext != nil {
+		return ext
+	}
+
+	if _, err := s.userRepo.ConfirmPendingEmail(existingToken.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewAppError(409, "Email is no longer available")
+		}
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Error updating account")
 	}
 
 	return nil
 }
 
-func (s *authService) LoginHandler(request *dto.LoginRequest) (*utils.TokenResponse, *utils.AppError) {
+// LoginHandler signs the user in on a new session. Unknown usernames and
+// wrong passwords get the same answer, and repeated failures back off and
+// eventually lock the account, mailing its owner an unlock link. Accounts
+// with two-factor authentication get a short-lived token to pass to
+// VerifyTwoFactorHandler with a code instead of the token pair.
+func (s *authService) LoginHandler(request *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, *utils.AppError) {
+	accountKey := accountAttemptKey(request.Username)
+	ipKey := ipAttemptKey(client.IP)
+
+	if ext := checkLoginThrottle(s.loginAttempts, accountKey, ipKey); ext != nil {
+		return nil, ext
+	}
+
 	user, err := s.userRepo.FindOneByUsername(request.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, utils.NewAppError(404, "Username or password is incorrect")
+		if errors.Is(err, sql.ErrNoRows) {
+			// Spend as long as a real password check would.
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(request.Password))
+			return nil, s.loginFailed(nil, accountKey, ipKey)
 		}
+		log.Msg.Error(err)
 		return nil, utils.NewAppError(500, "Internal server error")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.HashPassword), []byte(request.Password))
 	if err != nil {
-		return nil, utils.NewAppError(401, "Username or password is incorrect")
+		return nil, s.loginFailed(user, accountKey, ipKey)
 	}
 
 	log.Msg.Debug(user.EmailVerify)
@@ -180,55 +333,331 @@ func (s *authService) LoginHandler(request *dto.LoginRequest) (*utils.TokenRespo
 		return nil, utils.NewAppError(401, "Email not verified")
 	}
 
+	return s.signIn(user, accountKey, client)
+}
+
+// signIn finishes a sign-in once the first factor checked out: it asks for
+// a code when the account has two-factor authentication and otherwise
+// starts a session.
+func (s *authService) signIn(user *domain.User, accountKey string, client *dto.ClientInfo) (*dto.LoginResponse, *utils.AppError) {
+	twoFactor, err := s.userRepo.FindTwoFactor(user.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	// Failures are only forgotten after the second step, otherwise knowing
+	// the password would allow unlimited guesses at the code.
+	if twoFactor.Enabled {
+		token, ext := issueToken(s.tokenRepo, user, dto.TwoFactorToken, twoFactorTokenTTL)
+
+		if ext != nil {
+			return nil, ext
+		}
+
+		return &dto.LoginResponse{TwoFactorRequired: true, TwoFactorToken: token}, nil
+	}
+
+	tokens, ext := s.startSession(user, accountKey, client)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	return &dto.LoginResponse{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// VerifyTwoFactorHandler finishes signing in to an account with two-factor
+// authentication, given the token from LoginHandler and a TOTP or recovery
+// code. Wrong codes count as failed logins. The token stays valid until it
+// expires or a code is accepted.
+func (s *authService) VerifyTwoFactorHandler(request *dto.TwoFactorVerifyRequest, client *dto.ClientInfo) (*utils.TokenResponse, *utils.AppError) {
+	pending, err := s.tokenRepo.FindOneByValue(secret.Hash(request.Token))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(401, "Invalid two-factor token")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if pending.Name != dto.TwoFactorToken || pending.RevokedAt != nil {
+		return nil, utils.NewAppError(401, "Invalid two-factor token")
+	}
+
+	if pending.ExpiredAt != nil && pending.ExpiredAt.Before(time.Now()) {
+		return nil, utils.NewAppError(401, "Two-factor token expired, please sign in again")
+	}
+
+	user, err := s.userRepo.FindOneById(pending.UserID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(404, "User not found")
+	}
+
+	accountKey := accountAttemptKey(user.Username)
+	ipKey := ipAttemptKey(client.IP)
+
+	if ext := checkLoginThrottle(s.loginAttempts, accountKey, ipKey); ext != nil {
+		return nil, ext
+	}
+
+	twoFactor, err := s.userRepo.FindTwoFactor(user.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	ok, ext := verifySecondFactor(s.userRepo, s.tokenRepo, twoFactor, request.Code)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if !ok {
+		if ext := s.recordFailure(user, accountKey, ipKey); ext != nil {
+			return nil, ext
+		}
+		return nil, utils.NewAppError(401, "Invalid code")
+	}
+
+	used, err := s.tokenRepo.Revoke(pending.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if !used {
+		return nil, utils.NewAppError(401, "Invalid two-factor token")
+	}
+
+	return s.startSession(user, accountKey, client)
+}
+
+// startSession clears the failed logins of a user who just proved who they
+// are and signs them in on a new session.
+func (s *authService) startSession(user *domain.User, accountKey string, client *dto.ClientInfo) (*utils.TokenResponse, *utils.AppError) {
+	if err := s.loginAttempts.Reset(accountKey); err != nil {
+		log.Msg.Error(err)
+	}
+
 	roles, _ := s.roleRepo.FindRolesByUser(user.ID)
 
 	user.Roles = roles
 
-	var refreshToken string
-	var needNewRefreshToken bool = true
+	session, err := s.sessionRepo.Save(&domain.Session{
+		UserID:    user.ID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	})
 
-	existingToken, err := s.tokenRepo.FindOneByToken(dto.RefreshToken, user.ID)
 	if err != nil {
-		return nil, utils.NewAppError(500, "Error checking existing token")
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error creating session")
 	}
 
-	if existingToken != nil && existingToken.ExpiredAt != nil && existingToken.ExpiredAt.After(time.Now()) {
-		refreshToken = existingToken.Token
-		needNewRefreshToken = false
+	return s.issueTokens(user, session.ID)
+}
+
+func (s *authService) loginFailed(user *domain.User, accountKey string, ipKey string) *utils.AppError {
+	if ext := s.recordFailure(user, accountKey, ipKey); ext != nil {
+		return ext
 	}
 
-	token, err := utils.GenerateJWT(user)
+	return utils.NewAppError(401, "Username or password is incorrect")
+}
+
+// recordFailure counts a failed password or code and mails the owner once,
+// on the failure that locks the account.
+func (s *authService) recordFailure(user *domain.User, accountKey string, ipKey string) *utils.AppError {
+	attempts, err := recordLoginFailure(s.loginAttempts, accountKey, ipKey)
+
 	if err != nil {
-		return nil, utils.NewAppError(400, "Error generating JWT")
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
 	}
 
-	if needNewRefreshToken {
-		refreshToken = token.RefreshToken
-		expirationTime := time.Now().Add(30 * 24 * time.Hour)
+	if user != nil && attempts.Failures == domain.AccountLoginThrottle.LockoutAfter {
+		s.sendUnlock(user)
+	}
 
-		tokenEntity := &domain.Token{
-			UserID:    user.ID,
-			Name:      dto.RefreshToken,
-			Token:     refreshToken,
-			ExpiredAt: &expirationTime,
-		}
+	return nil
+}
+
+func (s *authService) sendUnlock(user *domain.User) {
+	log.Msg.Warnf("account %d locked after %d failed login attempts", user.ID, domain.AccountLoginThrottle.LockoutAfter)
 
-		if existingToken != nil {
-			tokenEntity.ID = existingToken.ID
-			_, err = s.tokenRepo.Update(tokenEntity)
-		} else {
-			_, err = s.tokenRepo.Insert(tokenEntity)
+	token, ext := issueToken(s.tokenRepo, user, dto.UnlockAccountToken, unlockTokenTTL)
+
+	if ext != nil {
+		return
+	}
+
+	if err := s.mail.SendMailUnlockAccount(user.Email, token); err != nil {
+		log.Msg.Error(err)
+	}
+}
+
+// UnlockAccountHandler lifts a lockout with the link mailed to the owner.
+func (s *authService) UnlockAccountHandler(token string) *utils.AppError {
+	existingToken, ext := consumeToken(s.tokenRepo, token, dto.UnlockAccountToken)
+
+	if ext != nil {
+		return ext
+	}
+
+	user, err := s.userRepo.FindOneById(existingToken.UserID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(404, "User not found")
+	}
+
+	if err := s.loginAttempts.Reset(accountAttemptKey(user.Username)); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	return nil
+}
+
+// RefreshHandler exchanges a refresh token for a new access token and a
+// rotated refresh token within the same session. Presenting a refresh token
+// that has already been rotated means it leaked, so every session of the
+// user is revoked.
+func (s *authService) RefreshHandler(request *dto.RefreshRequest, client *dto.ClientInfo) (*utils.TokenResponse, *utils.AppError) {
+	payload, err := utils.ValidateRefreshJWT(request.RefreshToken)
+
+	if err != nil {
+		return nil, utils.NewAppError(401, "Invalid refresh token")
+	}
+
+	existingToken, err := s.tokenRepo.FindOneByValue(secret.Hash(request.RefreshToken))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(401, "Invalid refresh token")
 		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if existingToken.Name != dto.RefreshToken || existingToken.UserID != payload.Sub {
+		return nil, utils.NewAppError(401, "Invalid refresh token")
+	}
+
+	if existingToken.RevokedAt != nil {
+		return nil, s.revokeTokenFamily(existingToken.UserID)
+	}
+
+	if existingToken.ExpiredAt != nil && existingToken.ExpiredAt.Before(time.Now()) {
+		return nil, utils.NewAppError(401, "Refresh token expired")
+	}
+
+	session, ext := s.refreshSession(existingToken, client)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	rotated, err := s.tokenRepo.Revoke(existingToken.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if !rotated {
+		return nil, s.revokeTokenFamily(existingToken.UserID)
+	}
+
+	user, err := s.userRepo.FindOneById(existingToken.UserID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(401, "Invalid refresh token")
+	}
+
+	roles, _ := s.roleRepo.FindRolesByUser(user.ID)
+
+	user.Roles = roles
+
+	return s.issueTokens(user, session.ID)
+}
+
+// refreshSession returns the active session of a refresh token and records
+// its use. Refresh tokens issued before sessions existed get a new session.
+func (s *authService) refreshSession(token *domain.Token, client *dto.ClientInfo) (*domain.Session, *utils.AppError) {
+	if token.SessionID == nil {
+		session, err := s.sessionRepo.Save(&domain.Session{
+			UserID:    token.UserID,
+			UserAgent: client.UserAgent,
+			IP:        client.IP,
+		})
 
 		if err != nil {
-			return nil, utils.NewAppError(500, "Error saving refresh token")
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Error creating session")
 		}
+
+		return session, nil
+	}
+
+	session, err := s.sessionRepo.FindOne(*token.SessionID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(401, "Invalid refresh token")
+	}
+
+	if session.RevokedAt != nil || session.UserID != token.UserID {
+		return nil, utils.NewAppError(401, "Session has been revoked")
 	}
 
-	tokenResponse := &utils.TokenResponse{
-		AccessToken:  token.AccessToken,
-		RefreshToken: refreshToken,
+	if err := s.sessionRepo.Touch(session.ID, client.IP); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	return session, nil
+}
+
+func (s *authService) revokeTokenFamily(userId int) *utils.AppError {
+	log.Msg.Warnf("refresh token reuse detected for user %d, revoking all sessions", userId)
+
+	if ext := revokeSessions(s.sessionRepo, s.tokenRepo, s.revocations, userId, 0); ext != nil {
+		return ext
+	}
+
+	return utils.NewAppError(401, "Refresh token has already been used")
+}
+
+// issueTokens signs a new token pair for the user's session and stores the
+// hash of the refresh token so it can be rotated later.
+func (s *authService) issueTokens(user *domain.User, sessionId int) (*utils.TokenResponse, *utils.AppError) {
+	token, err := utils.GenerateJWT(user, sessionId)
+	if err != nil {
+		return nil, utils.NewAppError(400, "Error generating JWT")
+	}
+
+	expirationTime := time.Now().Add(utils.RefreshTokenTTL)
+
+	tokenEntity := &domain.Token{
+		UserID:    user.ID,
+		Name:      dto.RefreshToken,
+		Token:     secret.Hash(token.RefreshToken),
+		ExpiredAt: &expirationTime,
+		SessionID: &sessionId,
+	}
+
+	if _, err = s.tokenRepo.Insert(tokenEntity); err != nil {
+		return nil, utils.NewAppError(500, "Error saving refresh token")
 	}
 
-	return tokenResponse, nil
+	return token, nil
 }
@@ -2,18 +2,46 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
 
+	"github.com/may20xx/booking/config"
+	"github.com/may20xx/booking/internal/api/dto"
 	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/policy"
+	"github.com/may20xx/booking/internal/pricing"
 	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
 	"github.com/may20xx/booking/pkg/log"
+	"github.com/may20xx/booking/pkg/payment"
 )
 
 type BookingService interface {
+	Save(payload *utils.JwtPayload, req *dto.BookingRequest) (*utils.Response, *utils.AppError)
+	FindDetail(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	FindAllForUser(payload *utils.JwtPayload, page string, limit string) (*utils.Pagination, *utils.AppError)
+	FindAllForListing(payload *utils.JwtPayload, listingId string, page string, limit string) (*utils.Pagination, *utils.AppError)
+	Quote(listingId string, start string, end string, guests string) (*utils.Response, *utils.AppError)
+	Accept(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	Decline(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	Cancel(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	CancelPreview(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	Complete(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
 }
 
 type bookingService struct {
 	bookingRepo storage.BookingRepository
 	paymentRepo storage.PaymentRepository
+	listingRepo storage.ListingRepository
+	policyRepo  storage.CancellationPolicyRepository
+	ruleRepo    storage.PriceRuleRepository
+	provider    payment.Provider
+	currency    string
 }
 
 func NewBookingService() BookingService {
@@ -27,5 +55,677 @@ func NewBookingService() BookingService {
 	return &bookingService{
 		bookingRepo: storage.NewBookingRepository(db, ctx),
 		paymentRepo: storage.NewPaymentRepository(db, ctx),
+		listingRepo: storage.NewListingRepository(db, ctx),
+		policyRepo:  storage.NewCancellationPolicyRepository(db, ctx),
+		ruleRepo:    storage.NewPriceRuleRepository(db, ctx),
+		provider:    paymentProvider(),
+		currency:    config.GetConfig().PaymentCurrency,
 	}
 }
+
+func (s *bookingService) Save(payload *utils.JwtPayload, req *dto.BookingRequest) (*utils.Response, *utils.AppError) {
+	startDate, endDate, ext := parseStay(req.StartDate, req.EndDate)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	listing, err := s.listingRepo.FindOne(req.ListingID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if listing.ArchivedAt != nil {
+		return nil, utils.NewAppError(400, "Listing is no longer available!")
+	}
+
+	if listing.LandlordID == payload.Sub {
+		return nil, utils.NewAppError(400, "You cannot book your own listing!")
+	}
+
+	if req.Guests > listing.Guests {
+		return nil, utils.NewAppError(400, "Number of guests exceeds the listing capacity!")
+	}
+
+	exists, err := s.bookingRepo.ExistBooking(listing.ID, startDate, endDate)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if exists {
+		return nil, utils.NewAppError(409, "Listing is not available for the selected dates!")
+	}
+
+	priceDetail, ext := s.quote(listing, startDate, endDate)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	newBooking := &domain.Booking{
+		ListingID:     listing.ID,
+		GuestID:       payload.Sub,
+		Guests:        req.Guests,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		Nights:        pricing.Nights(startDate, endDate),
+		PhoneNumber:   req.PhoneNumber,
+		MessageToHost: req.MessageToHost,
+		PriceDetail:   priceDetail,
+	}
+
+	booking, err := s.bookingRepo.Save(newBooking)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrBookingConflict) {
+			return nil, utils.NewAppError(409, "Listing is not available for the selected dates!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	booking.Listing = listing
+
+	return utils.NewResponse(201, booking), nil
+}
+
+func (s *bookingService) FindDetail(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	booking, ext := s.findBooking(id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	listing, err := s.listingRepo.FindOne(booking.ListingID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if ext := authorize(policy.ViewBooking(actorOf(payload), booking, listing)); ext != nil {
+		return nil, ext
+	}
+
+	priceDetail, err := s.bookingRepo.FindPriceDetail(booking.ID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	charge, err := s.paymentRepo.FindForBooking(booking.ID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	refunds, err := s.paymentRepo.FindRefundsForBooking(booking.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	booking.Listing = listing
+	booking.PriceDetail = priceDetail
+	booking.Payment = charge
+	booking.Refunds = refunds
+
+	return utils.NewResponse(200, booking), nil
+}
+
+func (s *bookingService) FindAllForUser(payload *utils.JwtPayload, page string, limit string) (*utils.Pagination, *utils.AppError) {
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt <= 0 {
+		pageInt = 1
+	}
+
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt <= 0 {
+		limitInt = 20
+	}
+
+	bookings, totalItems, totalPage, err := s.bookingRepo.FindAllForUser(payload.Sub, pageInt, limitInt)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewPaginationResponse(totalItems, totalPage, pageInt, limitInt, bookings), nil
+}
+
+func (s *bookingService) FindAllForListing(payload *utils.JwtPayload, listingId string, page string, limit string) (*utils.Pagination, *utils.AppError) {
+	listingIdInt, err := strconv.Atoi(listingId)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt <= 0 {
+		pageInt = 1
+	}
+
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt <= 0 {
+		limitInt = 20
+	}
+
+	listing, err := s.listingRepo.FindOne(listingIdInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if ext := authorize(policy.ManageListing(actorOf(payload), listing)); ext != nil {
+		return nil, ext
+	}
+
+	bookings, totalItems, totalPage, err := s.bookingRepo.FindAllForListing(listing.ID, pageInt, limitInt)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewPaginationResponse(totalItems, totalPage, pageInt, limitInt, bookings), nil
+}
+
+func (s *bookingService) Quote(listingId string, start string, end string, guests string) (*utils.Response, *utils.AppError) {
+	listingIdInt, err := strconv.Atoi(listingId)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	guestsInt, err := strconv.Atoi(guests)
+	if err != nil || guestsInt <= 0 {
+		guestsInt = 1
+	}
+
+	startDate, endDate, ext := parseStay(start, end)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	listing, err := s.listingRepo.FindOne(listingIdInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if listing.ArchivedAt != nil {
+		return nil, utils.NewAppError(400, "Listing is no longer available!")
+	}
+
+	if guestsInt > listing.Guests {
+		return nil, utils.NewAppError(400, "Number of guests exceeds the listing capacity!")
+	}
+
+	exists, err := s.bookingRepo.ExistBooking(listing.ID, startDate, endDate)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	priceDetail, ext := s.quote(listing, startDate, endDate)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	quote := &dto.QuoteResponse{
+		ListingID:   listing.ID,
+		StartDate:   startDate.Format(dto.DateLayout),
+		EndDate:     endDate.Format(dto.DateLayout),
+		Nights:      pricing.Nights(startDate, endDate),
+		Guests:      guestsInt,
+		Available:   !exists,
+		PriceDetail: priceDetail,
+	}
+
+	return utils.NewResponse(200, quote), nil
+}
+
+// Accept confirms a pending booking. The guest is charged the stored price
+// detail before the status changes; if the booking can no longer be
+// confirmed the charge is refunded.
+func (s *bookingService) Accept(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	booking, ext := s.findForHost(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if !booking.Status.CanTransitionTo(domain.BookingConfirmed) {
+		return nil, utils.NewAppError(409, fmt.Sprintf("Booking cannot be changed from %s to %s!", booking.Status, domain.BookingConfirmed))
+	}
+
+	priceDetail, err := s.bookingRepo.FindPriceDetail(booking.ID)
+
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, err.Error())
+		}
+		priceDetail, ext = s.quote(booking.Listing, booking.StartDate, booking.EndDate)
+
+		if ext != nil {
+			return nil, ext
+		}
+	}
+
+	charge, ext := s.charge(booking, priceDetail.TotalPrice)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	res, ext := s.changeStatus(booking, domain.BookingConfirmed)
+
+	if ext != nil {
+		if _, err := s.provider.Refund(*charge.ProviderRef, charge.Price); err != nil {
+			log.Msg.Errorf("error refunding payment %d: %s", charge.ID, err)
+			return nil, ext
+		}
+
+		charge.Status = string(payment.StatusRefunded)
+
+		if _, err := s.paymentRepo.Update(charge); err != nil {
+			log.Msg.Error(err)
+		}
+
+		return nil, ext
+	}
+
+	booking.PriceDetail = priceDetail
+	booking.Payment = charge
+
+	return res, nil
+}
+
+func (s *bookingService) Decline(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	booking, ext := s.findForHost(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	return s.changeStatus(booking, domain.BookingDeclined)
+}
+
+func (s *bookingService) Complete(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	booking, ext := s.findForHost(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if booking.EndDate.After(time.Now()) {
+		return nil, utils.NewAppError(400, "Booking can only be completed after check-out!")
+	}
+
+	return s.changeStatus(booking, domain.BookingCompleted)
+}
+
+func (s *bookingService) CancelPreview(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	booking, ext := s.findBooking(id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if ext := authorize(policy.CancelBooking(actorOf(payload), booking)); ext != nil {
+		return nil, ext
+	}
+
+	if !booking.Status.CanTransitionTo(domain.BookingCancelled) {
+		return nil, utils.NewAppError(409, fmt.Sprintf("Booking cannot be changed from %s to %s!", booking.Status, domain.BookingCancelled))
+	}
+
+	preview, _, ext := s.previewCancellation(booking, time.Now())
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	return utils.NewResponse(200, preview), nil
+}
+
+// Cancel cancels the guest's booking and refunds the share of the charge
+// allowed by the listing's cancellation policy.
+func (s *bookingService) Cancel(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	booking, ext := s.findBooking(id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if ext := authorize(policy.CancelBooking(actorOf(payload), booking)); ext != nil {
+		return nil, ext
+	}
+
+	if !booking.Status.CanTransitionTo(domain.BookingCancelled) {
+		return nil, utils.NewAppError(409, fmt.Sprintf("Booking cannot be changed from %s to %s!", booking.Status, domain.BookingCancelled))
+	}
+
+	preview, charge, ext := s.previewCancellation(booking, time.Now())
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	// The guest is refunded before the booking is cancelled, so a failed
+	// refund leaves the booking as it was and the cancellation can be retried.
+	if charge != nil && preview.RefundAmount > 0 {
+		refund, ext := s.refund(booking, charge, preview.RefundAmount)
+
+		if ext != nil {
+			return nil, ext
+		}
+
+		booking.Payment = charge
+		booking.Refunds = append(booking.Refunds, refund)
+	}
+
+	res, ext := s.changeStatus(booking, domain.BookingCancelled)
+
+	if ext != nil {
+		if len(booking.Refunds) > 0 {
+			log.Msg.Errorf("booking %d was refunded but could not be cancelled: %s", booking.ID, ext.Message)
+		}
+		return nil, ext
+	}
+
+	return res, nil
+}
+
+func (s *bookingService) findBooking(id string) (*domain.Booking, *utils.AppError) {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	booking, err := s.bookingRepo.FindDetail(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Booking not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return booking, nil
+}
+
+// findForHost loads a booking and makes sure the caller may act as the host
+// of the booked listing.
+func (s *bookingService) findForHost(payload *utils.JwtPayload, id string) (*domain.Booking, *utils.AppError) {
+	booking, ext := s.findBooking(id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	listing, err := s.listingRepo.FindOne(booking.ListingID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if ext := authorize(policy.ManageBooking(actorOf(payload), listing)); ext != nil {
+		return nil, ext
+	}
+
+	booking.Listing = listing
+
+	return booking, nil
+}
+
+func (s *bookingService) changeStatus(booking *domain.Booking, status domain.BookingStatus) (*utils.Response, *utils.AppError) {
+	if !booking.Status.CanTransitionTo(status) {
+		return nil, utils.NewAppError(409, fmt.Sprintf("Booking cannot be changed from %s to %s!", booking.Status, status))
+	}
+
+	booking, err := s.bookingRepo.UpdateStatus(booking, status)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrBookingStatusChanged) {
+			return nil, utils.NewAppError(409, "Booking has been updated by someone else, please try again!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, booking), nil
+}
+
+// charge authorizes and captures amount for the booking, recording the
+// provider reference on the booking's payment row.
+func (s *bookingService) charge(booking *domain.Booking, amount float64) (*domain.Payment, *utils.AppError) {
+	existing, err := s.paymentRepo.FindForBooking(booking.ID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if existing != nil && existing.Status == string(payment.StatusCaptured) {
+		return existing, nil
+	}
+
+	intent, err := s.provider.Authorize(amount, s.currency, fmt.Sprintf("Booking #%d", booking.ID))
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(402, "Payment authorization failed!")
+	}
+
+	record := existing
+
+	if record == nil {
+		record = &domain.Payment{
+			BookingID: booking.ID,
+			Name:      fmt.Sprintf("Booking #%d", booking.ID),
+			Provider:  s.provider.Name(),
+		}
+	}
+
+	record.Price = intent.Amount
+	record.ProviderRef = &intent.Reference
+	record.Status = string(intent.Status)
+	record.IsSuccessful = false
+
+	if existing == nil {
+		record, err = s.paymentRepo.Save(record)
+	} else {
+		record, err = s.paymentRepo.Update(record)
+	}
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	captured, err := s.provider.Capture(intent.Reference)
+
+	if err != nil {
+		log.Msg.Error(err)
+
+		record.Status = string(payment.StatusFailed)
+
+		if _, err := s.paymentRepo.Update(record); err != nil {
+			log.Msg.Error(err)
+		}
+
+		return nil, utils.NewAppError(402, "Payment capture failed!")
+	}
+
+	record.Status = string(captured.Status)
+	record.IsSuccessful = true
+
+	record, err = s.paymentRepo.Update(record)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return record, nil
+}
+
+// previewCancellation works out what the guest gets back when cancelling the
+// booking at cancelledAt. Only captured payments are refunded.
+func (s *bookingService) previewCancellation(booking *domain.Booking, cancelledAt time.Time) (*dto.CancelPreview, *domain.Payment, *utils.AppError) {
+	policy, err := findCancellationPolicy(s.policyRepo, booking.ListingID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, nil, utils.NewAppError(500, err.Error())
+	}
+
+	preview := &dto.CancelPreview{
+		BookingID:     booking.ID,
+		Policy:        policy,
+		RefundPercent: pricing.RefundPercent(policy, booking.StartDate, cancelledAt),
+	}
+
+	charge, err := s.paymentRepo.FindForBooking(booking.ID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return preview, nil, nil
+		}
+		log.Msg.Error(err)
+		return nil, nil, utils.NewAppError(500, err.Error())
+	}
+
+	if charge.Status != string(payment.StatusCaptured) {
+		return preview, nil, nil
+	}
+
+	priceDetail, err := s.bookingRepo.FindPriceDetail(booking.ID)
+
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Msg.Error(err)
+			return nil, nil, utils.NewAppError(500, err.Error())
+		}
+		priceDetail = &domain.PriceDetail{BookingID: booking.ID, TotalPrice: charge.Price}
+	}
+
+	preview.ChargedAmount = charge.Price
+	preview.RefundAmount = math.Min(pricing.Refund(policy, priceDetail, booking.StartDate, cancelledAt), charge.Price)
+
+	return preview, charge, nil
+}
+
+// refund pays amount of the charge back to the guest and records it. A
+// refund the provider declines is recorded as failed and reported as an
+// error; once the provider has paid out, failing to record it is only logged
+// so the caller still goes on to cancel the booking.
+func (s *bookingService) refund(booking *domain.Booking, charge *domain.Payment, amount float64) (*domain.Refund, *utils.AppError) {
+	record := &domain.Refund{
+		PaymentID: charge.ID,
+		BookingID: booking.ID,
+		Amount:    amount,
+		Status:    string(payment.StatusRefunded),
+	}
+
+	result, err := s.provider.Refund(*charge.ProviderRef, amount)
+
+	if err != nil {
+		log.Msg.Errorf("error refunding payment %d: %s", charge.ID, err)
+
+		record.Status = string(payment.StatusFailed)
+
+		if _, err := s.paymentRepo.SaveRefund(record); err != nil {
+			log.Msg.Error(err)
+		}
+
+		return nil, utils.NewAppError(502, "Refund failed, the booking was not cancelled!")
+	}
+
+	record.ProviderRef = &result.Reference
+
+	saved, err := s.paymentRepo.SaveRefund(record)
+
+	if err != nil {
+		log.Msg.Errorf("error recording refund %s of payment %d: %s", result.Reference, charge.ID, err)
+	} else {
+		record = saved
+	}
+
+	charge.Status = string(payment.RefundStatus(charge.Price, amount))
+
+	if _, err := s.paymentRepo.Update(charge); err != nil {
+		log.Msg.Errorf("error updating refunded payment %d: %s", charge.ID, err)
+	}
+
+	return record, nil
+}
+
+// quote prices the stay by the listing's current price rules.
+func (s *bookingService) quote(listing *domain.Listing, startDate time.Time, endDate time.Time) (*domain.PriceDetail, *utils.AppError) {
+	rules, err := s.ruleRepo.FindAllForListing(listing.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	listing.PriceRules = rules
+
+	return pricing.Quote(listing, startDate, endDate), nil
+}
+
+// parseStay parses the check-in and check-out dates of a stay and makes sure
+// the range covers at least one night and does not start in the past.
+func parseStay(start string, end string) (time.Time, time.Time, *utils.AppError) {
+	startDate, err := time.Parse(dto.DateLayout, start)
+
+	if err != nil {
+		return time.Time{}, time.Time{}, utils.NewAppError(400, "Invalid start date!")
+	}
+
+	endDate, err := time.Parse(dto.DateLayout, end)
+
+	if err != nil {
+		return time.Time{}, time.Time{}, utils.NewAppError(400, "Invalid end date!")
+	}
+
+	if !endDate.After(startDate) {
+		return time.Time{}, time.Time{}, utils.NewAppError(400, "End date must be after start date!")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if startDate.Before(today) {
+		return time.Time{}, time.Time{}, utils.NewAppError(400, "Start date must not be in the past!")
+	}
+
+	return startDate, endDate, nil
+}
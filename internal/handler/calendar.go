@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+const (
+	monthLayout       = "2006-01"
+	maxCalendarMonths = 12
+)
+
+// Calendar shows, night by night, which days of the listing can still be
+// booked over a number of whole months, starting with from (the current
+// month when empty).
+func (s *listingService) Calendar(id string, from string, months string) (*utils.Response, *utils.AppError) {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	now := time.Now().UTC()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if from != "" {
+		startDate, err = time.Parse(monthLayout, from)
+
+		if err != nil {
+			return nil, utils.NewAppError(400, "Invalid month!")
+		}
+	}
+
+	monthsInt, err := strconv.Atoi(months)
+	if err != nil || monthsInt <= 0 {
+		monthsInt = 1
+	}
+
+	if monthsInt > maxCalendarMonths {
+		monthsInt = maxCalendarMonths
+	}
+
+	endDate := startDate.AddDate(0, monthsInt, 0)
+
+	listing, err := s.listingRepo.FindOne(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	bookings, err := s.bookingRepo.FindActiveForListing(listing.ID, startDate, endDate)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	blocked, err := s.blockedDateRepo.FindAllForListing(listing.ID, startDate, endDate)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	calendar := &dto.CalendarResponse{
+		ListingID: listing.ID,
+		StartDate: startDate.Format(dto.DateLayout),
+		EndDate:   endDate.Format(dto.DateLayout),
+		Days:      domain.Calendar(startDate, endDate, bookings, blocked),
+	}
+
+	return utils.NewResponse(200, calendar), nil
+}
+
+func (s *listingService) BlockDates(payload *utils.JwtPayload, id string, req *dto.BlockDatesRequest) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	startDate, endDate, ext := parseStay(req.StartDate, req.EndDate)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	block, err := s.blockedDateRepo.Block(listing.ID, startDate, endDate)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrBlockOverlapsBooking) {
+			return nil, utils.NewAppError(409, "These dates are already booked!")
+		}
+		if errors.Is(err, storage.ErrBlockedDatesConflict) {
+			return nil, utils.NewAppError(409, "Blocked dates have been updated by someone else, please try again!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(201, block), nil
+}
+
+func (s *listingService) UnblockDates(payload *utils.JwtPayload, id string, req *dto.BlockDatesRequest) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	startDate, endDate, ext := parseStay(req.StartDate, req.EndDate)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if err := s.blockedDateRepo.Unblock(listing.ID, startDate, endDate); err != nil {
+		if errors.Is(err, storage.ErrBlockedDatesConflict) {
+			return nil, utils.NewAppError(409, "Blocked dates have been updated by someone else, please try again!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, "Unblocked dates successfully!"), nil
+}
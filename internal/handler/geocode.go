@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/may20xx/booking/config"
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/geocode"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+var (
+	geocoderInstance geocode.Geocoder
+	geocoderOnce     sync.Once
+)
+
+func geocoder() geocode.Geocoder {
+	geocoderOnce.Do(func() {
+		g, err := geocode.New(config.GetConfig().Geocoder)
+		if err != nil {
+			log.Msg.Panic("error creating geocoder %s", err)
+		}
+
+		geocoderInstance = g
+	})
+	return geocoderInstance
+}
+
+// place sets where the listing is on the map: at the coordinates the host
+// gave, or else at its geocoded location. A location the geocoder does not
+// know leaves the listing off the map rather than failing the request.
+func (s *listingService) place(listing *domain.Listing, req *dto.ListingRequest) *utils.AppError {
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return utils.NewAppError(400, "Latitude and longitude must be given together!")
+	}
+
+	if req.Latitude != nil {
+		point := domain.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+
+		if !point.Valid() {
+			return utils.NewAppError(400, "Invalid coordinates!")
+		}
+
+		listing.Latitude, listing.Longitude = req.Latitude, req.Longitude
+
+		return nil
+	}
+
+	point, err := s.geocoder.Geocode(context.Background(), listing.Location)
+
+	if err != nil {
+		if !errors.Is(err, geocode.ErrNotFound) {
+			log.Msg.Error(err)
+		}
+		listing.Latitude, listing.Longitude = nil, nil
+		return nil
+	}
+
+	listing.Latitude, listing.Longitude = &point.Latitude, &point.Longitude
+
+	return nil
+}
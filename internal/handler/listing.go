@@ -3,15 +3,18 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"mime/multipart"
 	"strconv"
 
 	"github.com/may20xx/booking/internal/api/dto"
 	"github.com/may20xx/booking/internal/database"
 	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/policy"
 	"github.com/may20xx/booking/internal/storage"
 	"github.com/may20xx/booking/internal/utils"
 	"github.com/may20xx/booking/pkg/cloudinary"
+	"github.com/may20xx/booking/pkg/geocode"
 	"github.com/may20xx/booking/pkg/log"
 )
 
@@ -19,17 +22,35 @@ type ListingService interface {
 	FindAll(page string, limit string) (*utils.Pagination, *utils.AppError)
 	FindDetail(id string) (*utils.Response, *utils.AppError)
 	Save(payload *utils.JwtPayload, req *dto.ListingRequest, files []multipart.File) (*utils.Response, error)
-	Update(id string, req *dto.ListingRequest) (*utils.Response, *utils.AppError)
-	Remove(id string) (*utils.Response, *utils.AppError)
-	Search(page string, limit string, query string) (*utils.Pagination, *utils.AppError)
+	Update(payload *utils.JwtPayload, id string, req *dto.ListingRequest) (*utils.Response, *utils.AppError)
+	Remove(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	SavePhotos(payload *utils.JwtPayload, id string, files []multipart.File) (*utils.Response, *utils.AppError)
+	RemovePhoto(payload *utils.JwtPayload, id string, photoId string) (*utils.Response, *utils.AppError)
+	Search(req *dto.ListingSearchRequest) (*utils.Pagination, *utils.AppError)
+	SearchAfter(req *dto.ListingSearchRequest) (*utils.CursorPagination, *utils.AppError)
+	Suggest(text string, limit string) (*utils.Response, *utils.AppError)
+	Clusters(req *dto.ListingSearchRequest) (*utils.Response, *utils.AppError)
+	FindCancellationPolicy(id string) (*utils.Response, *utils.AppError)
+	UpdateCancellationPolicy(payload *utils.JwtPayload, id string, req *dto.CancellationPolicyRequest) (*utils.Response, *utils.AppError)
+	Calendar(id string, from string, months string) (*utils.Response, *utils.AppError)
+	BlockDates(payload *utils.JwtPayload, id string, req *dto.BlockDatesRequest) (*utils.Response, *utils.AppError)
+	UnblockDates(payload *utils.JwtPayload, id string, req *dto.BlockDatesRequest) (*utils.Response, *utils.AppError)
+	FindPriceRules(id string) (*utils.Response, *utils.AppError)
+	UpdatePriceRules(payload *utils.JwtPayload, id string, req *dto.PriceRulesRequest) (*utils.Response, *utils.AppError)
 }
 
 type listingService struct {
-	cld         cloudinary.Cloudinary
-	listingRepo storage.ListingRepository
-	photoRepo   storage.PhotoRepository
-	userRepo    storage.UserRepository
-	catalogRepo storage.CatalogRepository
+	cld             cloudinary.Cloudinary
+	listingRepo     storage.ListingRepository
+	photoRepo       storage.PhotoRepository
+	userRepo        storage.UserRepository
+	catalogRepo     storage.CatalogRepository
+	policyRepo      storage.CancellationPolicyRepository
+	reviewRepo      storage.ReviewRepository
+	bookingRepo     storage.BookingRepository
+	blockedDateRepo storage.BlockedDateRepository
+	priceRuleRepo   storage.PriceRuleRepository
+	geocoder        geocode.Geocoder
 }
 
 func NewListingService() ListingService {
@@ -47,15 +68,21 @@ func NewListingService() ListingService {
 	}
 
 	return &listingService{
-		cld:         cld,
-		listingRepo: storage.NewListingRepository(db, ctx),
-		photoRepo:   storage.NewPhotoRepository(db, ctx),
-		userRepo:    storage.NewUserRepository(db, ctx),
-		catalogRepo: storage.NewCatalogRepository(db, ctx),
+		cld:             cld,
+		listingRepo:     storage.NewListingRepository(db, ctx),
+		photoRepo:       storage.NewPhotoRepository(db, ctx),
+		userRepo:        storage.NewUserRepository(db, ctx),
+		catalogRepo:     storage.NewCatalogRepository(db, ctx),
+		policyRepo:      storage.NewCancellationPolicyRepository(db, ctx),
+		reviewRepo:      storage.NewReviewRepository(db, ctx),
+		bookingRepo:     storage.NewBookingRepository(db, ctx),
+		blockedDateRepo: storage.NewBlockedDateRepository(db, ctx),
+		priceRuleRepo:   storage.NewPriceRuleRepository(db, ctx),
+		geocoder:        geocoder(),
 	}
 }
 
-func (s *listingService) Search(page string, limit string, query string) (*utils.Pagination, *utils.AppError) {
+func (s *listingService) FindAll(page string, limit string) (*utils.Pagination, *utils.AppError) {
 	pageInt, err := strconv.Atoi(page)
 	if err != nil || pageInt <= 0 {
 		pageInt = 1
@@ -66,29 +93,14 @@ func (s *listingService) Search(page string, limit string, query string) (*utils
 		limitInt = 20
 	}
 
-	listings, totalItems, totalPage, err := s.listingRepo.SearchByLocation(pageInt, limitInt, query)
+	listings, totalItems, totalPage, err := s.listingRepo.FindAll(pageInt, limitInt)
 
 	if err != nil {
 		return nil, utils.NewAppError(500, err.Error())
 	}
 
-	for _, listing := range listings {
-
-		landlord, err := s.userRepo.FindLandlord(listing.LandlordID)
-
-		if err != nil {
-			return nil, utils.NewAppError(500, err.Error())
-		}
-
-		listing.Landlord = landlord
-
-		photos, err := s.photoRepo.FindAllForListing(listing.ID)
-
-		if err != nil {
-			return nil, utils.NewAppError(500, err.Error())
-		}
-
-		listing.Photos = photos
+	if ext := s.withLandlordAndPhotos(listings); ext != nil {
+		return nil, ext
 	}
 
 	res := utils.NewPaginationResponse(totalItems, totalPage, pageInt, limitInt, listings)
@@ -96,29 +108,15 @@ func (s *listingService) Search(page string, limit string, query string) (*utils
 	return res, nil
 }
 
-func (s *listingService) FindAll(page string, limit string) (*utils.Pagination, *utils.AppError) {
-	pageInt, err := strconv.Atoi(page)
-	if err != nil || pageInt <= 0 {
-		pageInt = 1
-	}
-
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil || limitInt <= 0 {
-		limitInt = 20
-	}
-
-	listings, totalItems, totalPage, err := s.listingRepo.FindAll(pageInt, limitInt)
-
-	if err != nil {
-		return nil, utils.NewAppError(500, err.Error())
-	}
-
+// withLandlordAndPhotos fills in what a listing card shows besides the
+// listing itself.
+func (s *listingService) withLandlordAndPhotos(listings []*domain.Listing) *utils.AppError {
 	for _, listing := range listings {
 
 		landlord, err := s.userRepo.FindLandlord(listing.LandlordID)
 
 		if err != nil {
-			return nil, utils.NewAppError(500, err.Error())
+			return utils.NewAppError(500, err.Error())
 		}
 
 		listing.Landlord = landlord
@@ -126,15 +124,13 @@ func (s *listingService) FindAll(page string, limit string) (*utils.Pagination,
 		photos, err := s.photoRepo.FindAllForListing(listing.ID)
 
 		if err != nil {
-			return nil, utils.NewAppError(500, err.Error())
+			return utils.NewAppError(500, err.Error())
 		}
 
 		listing.Photos = photos
 	}
 
-	res := utils.NewPaginationResponse(totalItems, totalPage, pageInt, limitInt, listings)
-
-	return res, nil
+	return nil
 }
 
 func (s *listingService) Save(payload *utils.JwtPayload, req *dto.ListingRequest, files []multipart.File) (*utils.Response, error) {
@@ -169,6 +165,10 @@ func (s *listingService) Save(payload *utils.JwtPayload, req *dto.ListingRequest
 		Catalogs:    catalogs,
 	}
 
+	if ext := s.place(newListing, req); ext != nil {
+		return nil, ext
+	}
+
 	listing, err := s.listingRepo.Save(newListing)
 
 	if err != nil {
@@ -235,7 +235,7 @@ func (s *listingService) FindDetail(id string) (*utils.Response, *utils.AppError
 	listing, err := s.listingRepo.FindOne(idInt)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, utils.NewAppError(404, "Listing not found!")
 		}
 		log.Msg.Error(err)
@@ -269,43 +269,75 @@ func (s *listingService) FindDetail(id string) (*utils.Response, *utils.AppError
 
 	listing.Photos = photos
 
-	return utils.NewResponse(200, listing), nil
-}
+	policy, err := findCancellationPolicy(s.policyRepo, listing.ID)
 
-func (s *listingService) Remove(id string) (*utils.Response, *utils.AppError) {
-	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	listing.CancellationPolicy = policy
+
+	rules, err := s.priceRuleRepo.FindAllForListing(listing.ID)
 
 	if err != nil {
-		return nil, utils.NewAppError(400, "Invalid input")
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
 	}
 
-	err = s.listingRepo.Remove(idInt)
+	listing.PriceRules = rules
+
+	reviews, _, _, err := s.reviewRepo.FindAllForListing(listing.ID, 1, 10)
 
 	if err != nil {
 		log.Msg.Error(err)
 		return nil, utils.NewAppError(500, err.Error())
 	}
 
-	return utils.NewResponse(200, "Deleted listing successfully!"), nil
-}
+	if err := fillReviewAuthors(s.userRepo, reviews); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
 
-func (s *listingService) Update(id string, req *dto.ListingRequest) (*utils.Response, *utils.AppError) {
-	idInt, err := strconv.Atoi(id)
+	listing.Review = reviews
+
+	rating, reviewCount, err := s.reviewRepo.FindRating(listing.ID)
 
 	if err != nil {
-		return nil, utils.NewAppError(400, "Invalid input")
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
 	}
 
-	existingListing, err := s.listingRepo.FindOne(idInt)
+	listing.Rating = rating
+	listing.ReviewCount = reviewCount
+
+	return utils.NewResponse(200, listing), nil
+}
+
+func (s *listingService) Remove(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	err := s.listingRepo.Remove(listing.ID)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, utils.NewAppError(404, "Listing not found!")
-		}
 		log.Msg.Error(err)
 		return nil, utils.NewAppError(500, err.Error())
 	}
 
+	return utils.NewResponse(200, "Deleted listing successfully!"), nil
+}
+
+func (s *listingService) Update(payload *utils.JwtPayload, id string, req *dto.ListingRequest) (*utils.Response, *utils.AppError) {
+	existingListing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
 	existingListing.Title = req.Title
 	existingListing.Description = req.Description
 	existingListing.Price = req.Price
@@ -317,7 +349,11 @@ func (s *listingService) Update(id string, req *dto.ListingRequest) (*utils.Resp
 	existingListing.ServiceFee = req.ServiceFee
 	existingListing.Taxes = req.Taxes
 
-	listing, err := s.listingRepo.Update(idInt, existingListing)
+	if ext := s.place(existingListing, req); ext != nil {
+		return nil, ext
+	}
+
+	listing, err := s.listingRepo.Update(existingListing.ID, existingListing)
 
 	if err != nil {
 		log.Msg.Error(err)
@@ -326,3 +362,186 @@ func (s *listingService) Update(id string, req *dto.ListingRequest) (*utils.Resp
 
 	return utils.NewResponse(200, listing), nil
 }
+
+func (s *listingService) FindCancellationPolicy(id string) (*utils.Response, *utils.AppError) {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	listing, err := s.listingRepo.FindOne(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	policy, err := findCancellationPolicy(s.policyRepo, listing.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, policy), nil
+}
+
+func (s *listingService) UpdateCancellationPolicy(payload *utils.JwtPayload, id string, req *dto.CancellationPolicyRequest) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	policy, ok := domain.DefaultCancellationPolicy(req.Name)
+
+	if !ok {
+		return nil, utils.NewAppError(400, "Unknown cancellation policy!")
+	}
+
+	policy.ListingID = listing.ID
+
+	if req.CutoffDays != nil {
+		policy.CutoffDays = *req.CutoffDays
+	}
+
+	if req.RefundPercent != nil {
+		policy.RefundPercent = *req.RefundPercent
+	}
+
+	if req.LateRefundPercent != nil {
+		policy.LateRefundPercent = *req.LateRefundPercent
+	}
+
+	if policy.LateRefundPercent > policy.RefundPercent {
+		return nil, utils.NewAppError(400, "Late refund percent cannot exceed refund percent!")
+	}
+
+	policy, err := s.policyRepo.Upsert(policy)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, policy), nil
+}
+
+// findCancellationPolicy returns the policy a host picked for the listing,
+// falling back to the flexible preset when none was set.
+func findCancellationPolicy(repo storage.CancellationPolicyRepository, listingId int) (*domain.CancellationPolicy, error) {
+	policy, err := repo.FindForListing(listingId)
+
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		policy, _ = domain.DefaultCancellationPolicy(domain.PolicyFlexible)
+		policy.ListingID = listingId
+	}
+
+	return policy, nil
+}
+
+func (s *listingService) SavePhotos(payload *utils.JwtPayload, id string, files []multipart.File) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	var photos []*domain.Photo
+
+	for _, file := range files {
+		img, err := s.cld.UploadFile(file)
+
+		if err != nil {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, err.Error())
+		}
+
+		photo, err := s.photoRepo.Insert(&domain.Photo{
+			ListingID: listing.ID,
+			PublicID:  img.PublicID,
+			URL:       img.SecureURL,
+		})
+
+		if err != nil {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, err.Error())
+		}
+
+		photos = append(photos, photo)
+	}
+
+	return utils.NewResponse(201, photos), nil
+}
+
+func (s *listingService) RemovePhoto(payload *utils.JwtPayload, id string, photoId string) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	photoIdInt, err := strconv.Atoi(photoId)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	photo, err := s.photoRepo.FindOne(photoIdInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Photo not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if photo.ListingID != listing.ID {
+		return nil, utils.NewAppError(404, "Photo not found!")
+	}
+
+	if _, err := s.cld.DeleteFile(photo.PublicID); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if err := s.photoRepo.Remove(photo.PublicID); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, "Deleted photo successfully!"), nil
+}
+
+// findForLandlord loads a listing and makes sure the caller may manage it.
+func (s *listingService) findForLandlord(payload *utils.JwtPayload, id string) (*domain.Listing, *utils.AppError) {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	listing, err := s.listingRepo.FindOne(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if ext := authorize(policy.ManageListing(actorOf(payload), listing)); ext != nil {
+		return nil, ext
+	}
+
+	return listing, nil
+}
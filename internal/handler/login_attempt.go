@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+var (
+	loginAttempts     storage.LoginAttemptStore
+	loginAttemptsOnce sync.Once
+)
+
+// LoginAttempts returns the failed sign-in counts shared by every instance.
+// They live in Redis when configured and in memory otherwise.
+func LoginAttempts() storage.LoginAttemptStore {
+	loginAttemptsOnce.Do(func() {
+		if client := database.GetRedis(); client != nil {
+			loginAttempts = storage.NewRedisLoginAttemptStore(client, context.Background())
+			return
+		}
+		loginAttempts = storage.NewMemoryLoginAttemptStore()
+	})
+	return loginAttempts
+}
+
+// accountAttemptKey counts failures per username whether or not the account
+// exists, so throttling does not reveal which usernames are taken.
+func accountAttemptKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginThrottle rejects a sign-in while the account or the client
+// address is backing off or locked out.
+func checkLoginThrottle(store storage.LoginAttemptStore, accountKey string, ipKey string) *utils.AppError {
+	checks := []struct {
+		key      string
+		throttle domain.LoginThrottle
+	}{
+		{accountKey, domain.AccountLoginThrottle},
+		{ipKey, domain.IPLoginThrottle},
+	}
+
+	for _, check := range checks {
+		attempts, err := store.Find(check.key)
+
+		if err != nil {
+			log.Msg.Error(err)
+			return utils.NewAppError(500, "Internal server error")
+		}
+
+		if check.throttle.RetryAt(attempts).After(time.Now()) {
+			return utils.NewAppError(429, "Too many failed login attempts, please try again later")
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts a failed sign-in against the account and the
+// client address and returns the account's updated count.
+func recordLoginFailure(store storage.LoginAttemptStore, accountKey string, ipKey string) (*domain.LoginAttempts, error) {
+	if _, err := store.Fail(ipKey, domain.IPLoginThrottle.LockoutFor); err != nil {
+		return nil, err
+	}
+
+	return store.Fail(accountKey, domain.AccountLoginThrottle.LockoutFor)
+}
@@ -3,29 +3,57 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"mime/multipart"
+	"strconv"
+	"time"
 
 	"github.com/may20xx/booking/internal/api/dto"
 	"github.com/may20xx/booking/internal/database"
 	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/policy"
 	"github.com/may20xx/booking/internal/storage"
 	"github.com/may20xx/booking/internal/utils"
 	"github.com/may20xx/booking/pkg/cloudinary"
 	"github.com/may20xx/booking/pkg/log"
+	"github.com/may20xx/booking/pkg/mail"
+	"github.com/may20xx/booking/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type MeService interface {
 	Logout(payload *utils.JwtPayload) *utils.AppError
+	LogoutAll(payload *utils.JwtPayload) *utils.AppError
+	FindSessions(payload *utils.JwtPayload) (*utils.Response, *utils.AppError)
+	RevokeSession(payload *utils.JwtPayload, id string) *utils.AppError
 	GetProfile(payload *utils.JwtPayload) (*domain.User, *utils.AppError)
 	UploadAvatar(payload *utils.JwtPayload, file multipart.File) (*domain.User, *utils.AppError)
 	UpdateProfile(payload *utils.JwtPayload, req *dto.UpdateProfileRequest) (*domain.User, *utils.AppError)
+	ChangePassword(payload *utils.JwtPayload, req *dto.ChangePasswordRequest) *utils.AppError
+	ChangeEmail(payload *utils.JwtPayload, req *dto.ChangeEmailRequest) *utils.AppError
+	EnrollTwoFactor(payload *utils.JwtPayload) (*utils.Response, *utils.AppError)
+	ConfirmTwoFactor(payload *utils.JwtPayload, req *dto.TwoFactorConfirmRequest) (*utils.Response, *utils.AppError)
+	DisableTwoFactor(payload *utils.JwtPayload, req *dto.TwoFactorDisableRequest) *utils.AppError
+	RegenerateRecoveryCodes(payload *utils.JwtPayload, req *dto.TwoFactorCodeRequest) (*utils.Response, *utils.AppError)
+	RequestAccountDeletion(payload *utils.JwtPayload) *utils.AppError
+	DeleteAccount(payload *utils.JwtPayload, req *dto.DeleteAccountRequest) *utils.AppError
+	Export(payload *utils.JwtPayload) (*dto.PersonalDataExport, *utils.AppError)
 }
 
 type meService struct {
-	userRepo   storage.UserRepository
-	roleRepo   storage.RoleStorage
-	tokenRepo  storage.TokenStorage
-	cloudinary cloudinary.Cloudinary
+	userRepo     storage.UserRepository
+	roleRepo     storage.RoleStorage
+	tokenRepo    storage.TokenStorage
+	sessionRepo  storage.SessionRepository
+	identityRepo storage.IdentityRepository
+	listingRepo  storage.ListingRepository
+	photoRepo    storage.PhotoRepository
+	bookingRepo  storage.BookingRepository
+	paymentRepo  storage.PaymentRepository
+	reviewRepo   storage.ReviewRepository
+	revocations  storage.RevocationStore
+	cloudinary   cloudinary.Cloudinary
+	mail         mail.Mail
 }
 
 func NewMeService() *meService {
@@ -44,28 +72,97 @@ func NewMeService() *meService {
 	}
 
 	return &meService{
-		userRepo:   storage.NewUserRepository(db, ctx),
-		roleRepo:   storage.NewRoleRepository(db, ctx),
-		tokenRepo:  storage.NewTokenRepository(db, ctx),
-		cloudinary: upload,
+		userRepo:     storage.NewUserRepository(db, ctx),
+		roleRepo:     storage.NewRoleRepository(db, ctx),
+		tokenRepo:    storage.NewTokenRepository(db, ctx),
+		sessionRepo:  storage.NewSessionRepository(db, ctx),
+		identityRepo: storage.NewIdentityRepository(db, ctx),
+		listingRepo:  storage.NewListingRepository(db, ctx),
+		photoRepo:    storage.NewPhotoRepository(db, ctx),
+		bookingRepo:  storage.NewBookingRepository(db, ctx),
+		paymentRepo:  storage.NewPaymentRepository(db, ctx),
+		reviewRepo:   storage.NewReviewRepository(db, ctx),
+		revocations:  Revocations(),
+		cloudinary:   upload,
+		mail:         mail.NewMailService(),
 	}
 }
 
+// Logout ends the session the access token belongs to. Tokens issued before
+// sessions existed carry no session, so they sign the user out everywhere.
 func (s *meService) Logout(payload *utils.JwtPayload) *utils.AppError {
+	if payload.Sid == 0 {
+		return s.LogoutAll(payload)
+	}
 
-	token, _ := s.tokenRepo.FindOneByToken(dto.RefreshToken, payload.Sub)
+	if ext := s.revokeSession(payload.Sid); ext != nil {
+		return ext
+	}
 
-	if token != nil {
-		err := s.tokenRepo.Remove(token.ID)
+	if payload.Jti == "" {
+		return nil
+	}
 
-		if err != nil {
-			return utils.NewAppError(500, err.Error())
-		}
+	if err := s.revocations.RevokeToken(payload.Jti, utils.AccessTokenTTL); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
 	}
 
 	return nil
 }
 
+func (s *meService) LogoutAll(payload *utils.JwtPayload) *utils.AppError {
+	return revokeSessions(s.sessionRepo, s.tokenRepo, s.revocations, payload.Sub, 0)
+}
+
+func (s *meService) FindSessions(payload *utils.JwtPayload) (*utils.Response, *utils.AppError) {
+	sessions, err := s.sessionRepo.FindAllForUser(payload.Sub)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == payload.Sid
+	}
+
+	return utils.NewResponse(200, sessions), nil
+}
+
+func (s *meService) RevokeSession(payload *utils.JwtPayload, id string) *utils.AppError {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return utils.NewAppError(400, "Invalid input")
+	}
+
+	session, err := s.sessionRepo.FindOne(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewAppError(404, "Session not found")
+		}
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	if ext := authorize(policy.Owns(actorOf(payload), session.UserID)); ext != nil {
+		return ext
+	}
+
+	return s.revokeSession(session.ID)
+}
+
+func (s *meService) revokeSession(id int) *utils.AppError {
+	if err := s.sessionRepo.Revoke(id); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	return revokeAccessTokens(s.revocations, id)
+}
+
 func (s *meService) GetProfile(payload *utils.JwtPayload) (*domain.User, *utils.AppError) {
 	user, err := s.userRepo.FindOneById(payload.Sub)
 
@@ -122,7 +219,6 @@ func (s *meService) UpdateProfile(payload *utils.JwtPayload, req *dto.UpdateProf
 
 	user.FirstName = req.FirstName
 	user.Surname = req.Surname
-	user.Email = req.Email
 
 	res, err := s.userRepo.Update(user)
 
@@ -133,3 +229,252 @@ func (s *meService) UpdateProfile(payload *utils.JwtPayload, req *dto.UpdateProf
 	return res, nil
 
 }
+
+// ChangePassword replaces the password after checking the current one and
+// signs the user out of their other sessions.
+func (s *meService) ChangePassword(payload *utils.JwtPayload, req *dto.ChangePasswordRequest) *utils.AppError {
+	user, ext := s.findWithPassword(payload, req.CurrentPassword)
+
+	if ext != nil {
+		return ext
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+
+	if err != nil {
+		return utils.NewAppError(500, err.Error())
+	}
+
+	user.HashPassword = string(hash)
+
+	if _, err := s.userRepo.Update(user); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Error updating account")
+	}
+
+	return revokeSessions(s.sessionRepo, s.tokenRepo, s.revocations, user.ID, payload.Sid)
+}
+
+// ChangeEmail stores the new address as pending and mails a confirmation link
+// to it. The current address stays in use until the link is opened.
+func (s *meService) ChangeEmail(payload *utils.JwtPayload, req *dto.ChangeEmailRequest) *utils.AppError {
+	user, ext := s.findWithPassword(payload, req.Password)
+
+	if ext != nil {
+		return ext
+	}
+
+	if user.Email == req.Email {
+		return utils.NewAppError(400, "New email must be different from the current one")
+	}
+
+	if existingUser, _ := s.userRepo.FindOneByEmail(req.Email); existingUser != nil {
+		return utils.NewAppError(409, "Email is already in use")
+	}
+
+	if err := s.userRepo.UpdatePendingEmail(user.ID, &req.Email); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(user.ID, dto.EmailChangeToken); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	token, ext := issueToken(s.tokenRepo, user, dto.EmailChangeToken, verificationTokenTTL)
+
+	if ext != nil {
+		return ext
+	}
+
+	if err := s.mail.SendMailConfirmEmailChange(req.Email, token); err != nil {
+		return utils.NewAppError(500, "Mail send failed")
+	}
+
+	return nil
+}
+
+func (s *meService) findWithPassword(payload *utils.JwtPayload, password string) (*domain.User, *utils.AppError) {
+	user, err := s.userRepo.FindOneById(payload.Sub)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(404, "User not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.HashPassword), []byte(password)); err != nil {
+		return nil, utils.NewAppError(401, "Current password is incorrect")
+	}
+
+	return user, nil
+}
+
+// EnrollTwoFactor generates a new TOTP secret for the user to add to an
+// authenticator app. Codes are not required until ConfirmTwoFactor.
+func (s *meService) EnrollTwoFactor(payload *utils.JwtPayload) (*utils.Response, *utils.AppError) {
+	twoFactor, ext := s.findTwoFactor(payload.Sub)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if twoFactor.Enabled {
+		return nil, utils.NewAppError(409, "Two-factor authentication is already enabled")
+	}
+
+	user, err := s.userRepo.FindOneById(payload.Sub)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(404, "User not found")
+	}
+
+	key, err := totp.NewSecret()
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error generating secret")
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(user.ID, &key, false); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error updating account")
+	}
+
+	return utils.NewResponse(200, &dto.TwoFactorEnrollResponse{
+		Secret: key,
+		URI:    totp.URI(twoFactorIssuer, user.Email, key),
+	}), nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on once the user proves
+// their password and that their app produces valid codes, and hands out the
+// recovery codes.
+func (s *meService) ConfirmTwoFactor(payload *utils.JwtPayload, req *dto.TwoFactorConfirmRequest) (*utils.Response, *utils.AppError) {
+	if _, ext := s.findWithPassword(payload, req.Password); ext != nil {
+		return nil, ext
+	}
+
+	twoFactor, ext := s.findTwoFactor(payload.Sub)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if twoFactor.Enabled {
+		return nil, utils.NewAppError(409, "Two-factor authentication is already enabled")
+	}
+
+	if twoFactor.Secret == nil {
+		return nil, utils.NewAppError(400, "Two-factor enrollment has not been started")
+	}
+
+	counter, ok := totp.Verify(*twoFactor.Secret, req.Code, time.Now())
+
+	if !ok {
+		return nil, utils.NewAppError(400, "Invalid code")
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(twoFactor.UserID, twoFactor.Secret, true); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error updating account")
+	}
+
+	if _, err := s.userRepo.UseTwoFactorCounter(twoFactor.UserID, counter); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error updating account")
+	}
+
+	codes, ext := issueRecoveryCodes(s.tokenRepo, twoFactor.UserID)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	return utils.NewResponse(200, &dto.RecoveryCodesResponse{RecoveryCodes: codes}), nil
+}
+
+// DisableTwoFactor turns two-factor authentication off. It asks for both the
+// password and a code, so a stolen session alone cannot weaken the account.
+func (s *meService) DisableTwoFactor(payload *utils.JwtPayload, req *dto.TwoFactorDisableRequest) *utils.AppError {
+	if _, ext := s.findWithPassword(payload, req.Password); ext != nil {
+		return ext
+	}
+
+	twoFactor, ext := s.findEnabledTwoFactor(payload.Sub, req.Code)
+
+	if ext != nil {
+		return ext
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(twoFactor.UserID, nil, false); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Error updating account")
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(twoFactor.UserID, dto.RecoveryCode); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, invalidating the
+// remaining old ones.
+func (s *meService) RegenerateRecoveryCodes(payload *utils.JwtPayload, req *dto.TwoFactorCodeRequest) (*utils.Response, *utils.AppError) {
+	twoFactor, ext := s.findEnabledTwoFactor(payload.Sub, req.Code)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	codes, ext := issueRecoveryCodes(s.tokenRepo, twoFactor.UserID)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	return utils.NewResponse(200, &dto.RecoveryCodesResponse{RecoveryCodes: codes}), nil
+}
+
+func (s *meService) findTwoFactor(userId int) (*domain.TwoFactor, *utils.AppError) {
+	twoFactor, err := s.userRepo.FindTwoFactor(userId)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "User not found")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	return twoFactor, nil
+}
+
+// findEnabledTwoFactor loads the settings of an account with two-factor
+// authentication on and checks code against them.
+func (s *meService) findEnabledTwoFactor(userId int, code string) (*domain.TwoFactor, *utils.AppError) {
+	twoFactor, ext := s.findTwoFactor(userId)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if !twoFactor.Enabled {
+		return nil, utils.NewAppError(400, "Two-factor authentication is not enabled")
+	}
+
+	ok, ext := verifySecondFactor(s.userRepo, s.tokenRepo, twoFactor, code)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if !ok {
+		return nil, utils.NewAppError(400, "Invalid code")
+	}
+
+	return twoFactor, nil
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/may20xx/booking/config"
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+	"github.com/may20xx/booking/pkg/oidc"
+	"github.com/may20xx/booking/pkg/secret"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// oidcStateTTL is how long a user has to sign in at the provider.
+	oidcStateTTL    = 10 * time.Minute
+	oidcExchangeTTL = 15 * time.Second
+)
+
+var (
+	oidcStates     storage.OIDCStateStore
+	oidcStatesOnce sync.Once
+
+	usernameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+// OIDCStates returns the external sign-ins in progress, shared by every
+// instance. They live in Redis when configured and in memory otherwise.
+func OIDCStates() storage.OIDCStateStore {
+	oidcStatesOnce.Do(func() {
+		if client := database.GetRedis(); client != nil {
+			oidcStates = storage.NewRedisOIDCStateStore(client, context.Background())
+			return
+		}
+		oidcStates = storage.NewMemoryOIDCStateStore()
+	})
+	return oidcStates
+}
+
+// newOIDCProviders builds the identity providers enabled in the config.
+func newOIDCProviders() map[string]oidc.Provider {
+	setting := config.GetConfig()
+	providers := make(map[string]oidc.Provider, len(setting.OIDCProviders))
+
+	for _, provider := range setting.OIDCProviders {
+		cfg := oidc.Config{
+			Name:         provider.Name,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  setting.ServerURL + "/api/v1/auth/oidc/" + provider.Name + "/callback",
+		}
+
+		if provider.Name == "github" {
+			providers[provider.Name] = oidc.NewGitHub(cfg)
+		} else {
+			providers[provider.Name] = oidc.New(cfg)
+		}
+	}
+
+	return providers
+}
+
+// OIDCAuthURLHandler starts an external sign-in and returns the provider URL
+// to send the user to.
+func (s *authService) OIDCAuthURLHandler(provider string) (string, *utils.AppError) {
+	p, ok := s.providers[provider]
+
+	if !ok {
+		return "", utils.NewAppError(404, "Unknown identity provider")
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(500, "Internal server error")
+	}
+
+	nonce, err := oidc.NewState()
+	if err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(500, "Internal server error")
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(500, "Internal server error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcExchangeTTL)
+	defer cancel()
+
+	url, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(502, "Identity provider is unavailable")
+	}
+
+	err = s.oidcStates.Save(state, &domain.OIDCState{Provider: provider, Nonce: nonce, Verifier: verifier}, oidcStateTTL)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(500, "Internal server error")
+	}
+
+	return url, nil
+}
+
+// OIDCCallbackHandler finishes an external sign-in. The identity is matched
+// to a linked account first, then to an account with the same verified
+// email, and otherwise a new account is created.
+func (s *authService) OIDCCallbackHandler(provider string, code string, state string, client *dto.ClientInfo) (*dto.LoginResponse, *utils.AppError) {
+	p, ok := s.providers[provider]
+
+	if !ok {
+		return nil, utils.NewAppError(404, "Unknown identity provider")
+	}
+
+	pending, err := s.oidcStates.Take(state)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrStateNotFound) {
+			return nil, utils.NewAppError(400, "Sign-in has expired, please try again")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if pending.Provider != provider {
+		return nil, utils.NewAppError(400, "Sign-in has expired, please try again")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcExchangeTTL)
+	defer cancel()
+
+	identity, err := p.Exchange(ctx, code, pending.Verifier, pending.Nonce)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(401, "External sign-in failed")
+	}
+
+	user, ext := s.findIdentityUser(identity)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if !user.EmailVerify {
+		return nil, utils.NewAppError(401, "Email not verified")
+	}
+
+	return s.signIn(user, accountAttemptKey(user.Username), client)
+}
+
+func (s *authService) findIdentityUser(identity *oidc.Identity) (*domain.User, *utils.AppError) {
+	linked, err := s.identityRepo.FindOne(identity.Provider, identity.Subject)
+
+	if err == nil {
+		return s.linkedUser(linked)
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if identity.Email == "" {
+		return nil, utils.NewAppError(400, "The identity provider did not share an email address")
+	}
+
+	user, err := s.userRepo.FindOneByEmail(identity.Email)
+
+	switch {
+	case err == nil:
+		// Only link when both sides have proven they own the address, so an
+		// unverified address at either end cannot take over the account.
+		if !identity.EmailVerified || !user.EmailVerify {
+			return nil, utils.NewAppError(409, "An account with this email already exists, sign in with your password")
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		if user, err = s.createIdentityUser(identity); err != nil {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Error creating account")
+		}
+	default:
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	_, err = s.identityRepo.Save(&domain.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    &identity.Email,
+	})
+
+	if err != nil {
+		// A concurrent first sign-in with the same identity linked it first,
+		// so sign in whoever it was linked to.
+		if errors.Is(err, storage.ErrIdentityLinked) {
+			linked, err := s.identityRepo.FindOne(identity.Provider, identity.Subject)
+
+			if err != nil {
+				log.Msg.Error(err)
+				return nil, utils.NewAppError(500, "Error linking account")
+			}
+
+			return s.linkedUser(linked)
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error linking account")
+	}
+
+	return user, nil
+}
+
+func (s *authService) linkedUser(linked *domain.UserIdentity) (*domain.User, *utils.AppError) {
+	user, err := s.userRepo.FindOneById(linked.UserID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(404, "User not found")
+	}
+
+	return user, nil
+}
+
+// createIdentityUser registers an account for an external identity. It has
+// an unusable random password until the user resets it, and its email is
+// verified if the provider says so; otherwise a verification email is sent.
+func (s *authService) createIdentityUser(identity *oidc.Identity) (*domain.User, error) {
+	username, err := s.availableUsername(identity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	password, _, err := secret.NewToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.FindRoleByName(dto.User)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Insert(&domain.User{
+		Username:     username,
+		Email:        identity.Email,
+		HashPassword: string(hash),
+		FirstName:    identity.GivenName,
+		Surname:      identity.FamilyName,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.InsertRoleToUser(&domain.UserRole{UserID: user.ID, RoleID: role.ID}); err != nil {
+		return nil, err
+	}
+
+	if !identity.EmailVerified {
+		if ext := s.sendVerification(user); ext != nil {
+			return nil, ext
+		}
+		return user, nil
+	}
+
+	user.EmailVerify = true
+
+	if _, err := s.userRepo.VerifyEmail(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// availableUsername derives a free username from the provider's username or
+// the email's local part, adding a number when it is taken.
+func (s *authService) availableUsername(identity *oidc.Identity) (string, error) {
+	base := identity.Username
+
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	base = usernameInvalidChars.ReplaceAllString(strings.ToLower(base), "")
+
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+
+	for i := 0; i < 5; i++ {
+		_, err := s.userRepo.FindOneByUsername(candidate)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return candidate, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		candidate = fmt.Sprintf("%s%04d", base, rand.IntN(10000))
+	}
+
+	return "", fmt.Errorf("no available username for %s", base)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/may20xx/booking/config"
+	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+	"github.com/may20xx/booking/pkg/payment"
+)
+
+var (
+	provider     payment.Provider
+	providerOnce sync.Once
+)
+
+// paymentProvider returns the gateway shared by every service, so intents
+// created while confirming a booking can later be refunded or reconciled.
+func paymentProvider() payment.Provider {
+	providerOnce.Do(func() {
+		setting := config.GetConfig()
+
+		p, err := payment.NewProvider(setting.PaymentProvider, setting.PaymentSecret)
+		if err != nil {
+			log.Msg.Panic("error creating payment provider %s", err)
+		}
+
+		provider = p
+	})
+	return provider
+}
+
+type PaymentService interface {
+	HandleWebhook(body []byte, signature string) *utils.AppError
+}
+
+type paymentService struct {
+	provider    payment.Provider
+	paymentRepo storage.PaymentRepository
+}
+
+func NewPaymentService() PaymentService {
+	ctx := context.Background()
+
+	db, err := database.GetDatabase(ctx)
+	if err != nil {
+		log.Msg.Panic("error getting database connection %s", err)
+	}
+
+	return &paymentService{
+		provider:    paymentProvider(),
+		paymentRepo: storage.NewPaymentRepository(db, ctx),
+	}
+}
+
+func (s *paymentService) HandleWebhook(body []byte, signature string) *utils.AppError {
+	event, err := s.provider.VerifyWebhook(body, signature)
+
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			return utils.NewAppError(401, "Invalid signature")
+		}
+		return utils.NewAppError(400, err.Error())
+	}
+
+	existing, err := s.paymentRepo.FindByProviderRef(s.provider.Name(), event.Reference)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewAppError(404, "Payment not found!")
+		}
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	switch event.Type {
+	case payment.EventCaptured:
+		existing.Status = string(payment.StatusCaptured)
+		existing.IsSuccessful = true
+	case payment.EventRefunded:
+		existing.Status = string(payment.RefundStatus(existing.Price, event.Amount))
+	case payment.EventFailed:
+		existing.Status = string(payment.StatusFailed)
+		existing.IsSuccessful = false
+	default:
+		log.Msg.Warnf("Ignoring payment event %s", event.Type)
+		return nil
+	}
+
+	if _, err := s.paymentRepo.Update(existing); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"github.com/may20xx/booking/internal/policy"
+	"github.com/may20xx/booking/internal/utils"
+)
+
+func actorOf(payload *utils.JwtPayload) policy.Actor {
+	return policy.Actor{ID: payload.Sub, Roles: payload.Roles}
+}
+
+// authorize turns a policy decision into the API's access denied error.
+func authorize(err error) *utils.AppError {
+	if err != nil {
+		return utils.NewAppError(403, "Access denied")
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+func (s *listingService) FindPriceRules(id string) (*utils.Response, *utils.AppError) {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	listing, err := s.listingRepo.FindOne(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Listing not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	rules, err := s.priceRuleRepo.FindAllForListing(listing.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if rules == nil {
+		rules = []*domain.PriceRule{}
+	}
+
+	return utils.NewResponse(200, rules), nil
+}
+
+func (s *listingService) UpdatePriceRules(payload *utils.JwtPayload, id string, req *dto.PriceRulesRequest) (*utils.Response, *utils.AppError) {
+	listing, ext := s.findForLandlord(payload, id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	rules, ext := priceRulesOf(req)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	rules, err := s.priceRuleRepo.Replace(listing.ID, rules)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, rules), nil
+}
+
+// priceRulesOf turns a request into the rules it describes. Seasons may not
+// overlap, since a night can only have one seasonal price.
+func priceRulesOf(req *dto.PriceRulesRequest) ([]*domain.PriceRule, *utils.AppError) {
+	rules := []*domain.PriceRule{}
+
+	percents := []struct {
+		kind    domain.PriceRuleKind
+		percent *float64
+	}{
+		{domain.RuleWeekend, req.WeekendPercent},
+		{domain.RuleWeekly, req.WeeklyDiscount},
+		{domain.RuleMonthly, req.MonthlyDiscount},
+	}
+
+	for _, p := range percents {
+		if p.percent != nil && *p.percent > 0 {
+			rules = append(rules, &domain.PriceRule{Kind: p.kind, Percent: p.percent})
+		}
+	}
+
+	var seasons []*domain.PriceRule
+
+	for _, season := range req.Seasons {
+		startDate, err := time.Parse(dto.DateLayout, season.StartDate)
+
+		if err != nil {
+			return nil, utils.NewAppError(400, "Invalid season start date!")
+		}
+
+		endDate, err := time.Parse(dto.DateLayout, season.EndDate)
+
+		if err != nil {
+			return nil, utils.NewAppError(400, "Invalid season end date!")
+		}
+
+		if !endDate.After(startDate) {
+			return nil, utils.NewAppError(400, "Season end date must be after its start date!")
+		}
+
+		price := season.Price
+		seasons = append(seasons, &domain.PriceRule{Kind: domain.RuleSeason, Price: &price, StartDate: &startDate, EndDate: &endDate})
+	}
+
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].StartDate.Before(*seasons[j].StartDate)
+	})
+
+	for i := 1; i < len(seasons); i++ {
+		if seasons[i].StartDate.Before(*seasons[i-1].EndDate) {
+			return nil, utils.NewAppError(400, "Seasons must not overlap!")
+		}
+	}
+
+	return append(rules, seasons...), nil
+}
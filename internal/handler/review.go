@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/policy"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+type ReviewService interface {
+	Save(payload *utils.JwtPayload, req *dto.ReviewRequest) (*utils.Response, *utils.AppError)
+	Update(payload *utils.JwtPayload, id string, req *dto.UpdateReviewRequest) (*utils.Response, *utils.AppError)
+	Unpublish(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError)
+	FindAllForListing(listingId string, page string, limit string) (*utils.Pagination, *utils.AppError)
+}
+
+type reviewService struct {
+	reviewRepo  storage.ReviewRepository
+	bookingRepo storage.BookingRepository
+	listingRepo storage.ListingRepository
+	userRepo    storage.UserRepository
+}
+
+func NewReviewService() ReviewService {
+	ctx := context.Background()
+
+	db, err := database.GetDatabase(ctx)
+	if err != nil {
+		log.Msg.Panic("error getting database connection %s", err)
+	}
+
+	return &reviewService{
+		reviewRepo:  storage.NewReviewRepository(db, ctx),
+		bookingRepo: storage.NewBookingRepository(db, ctx),
+		listingRepo: storage.NewListingRepository(db, ctx),
+		userRepo:    storage.NewUserRepository(db, ctx),
+	}
+}
+
+// Save reviews the listing of a completed booking. A confirmed booking whose
+// check-out date has passed is completed on the way.
+func (s *reviewService) Save(payload *utils.JwtPayload, req *dto.ReviewRequest) (*utils.Response, *utils.AppError) {
+	booking, err := s.bookingRepo.FindDetail(req.BookingID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Booking not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if booking.GuestID != payload.Sub {
+		return nil, utils.NewAppError(403, "Only the guest of the booking can review it!")
+	}
+
+	if booking.Status == domain.BookingConfirmed && !booking.EndDate.After(time.Now()) {
+		booking, err = s.bookingRepo.UpdateStatus(booking, domain.BookingCompleted)
+
+		if err != nil {
+			if errors.Is(err, storage.ErrBookingStatusChanged) {
+				return nil, utils.NewAppError(409, "Booking has been updated by someone else, please try again!")
+			}
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, err.Error())
+		}
+	}
+
+	if booking.Status != domain.BookingCompleted {
+		return nil, utils.NewAppError(400, "Only completed bookings can be reviewed!")
+	}
+
+	review, err := s.reviewRepo.Save(&domain.Review{
+		ListingID: booking.ListingID,
+		AuthorID:  payload.Sub,
+		BookingID: booking.ID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+	})
+
+	if err != nil {
+		if errors.Is(err, storage.ErrReviewExists) {
+			return nil, utils.NewAppError(409, "Booking has already been reviewed!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(201, review), nil
+}
+
+func (s *reviewService) Update(payload *utils.JwtPayload, id string, req *dto.UpdateReviewRequest) (*utils.Response, *utils.AppError) {
+	review, ext := s.findReview(id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if ext := authorize(policy.EditReview(actorOf(payload), review)); ext != nil {
+		return nil, ext
+	}
+
+	review.Rating = req.Rating
+	review.Comment = req.Comment
+	review.IsEdited = true
+
+	review, err := s.reviewRepo.Update(review)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, review), nil
+}
+
+// Unpublish hides a review from the listing. Only the listing's landlord and
+// admins may do so.
+func (s *reviewService) Unpublish(payload *utils.JwtPayload, id string) (*utils.Response, *utils.AppError) {
+	review, ext := s.findReview(id)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	listing, err := s.listingRepo.FindOne(review.ListingID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if ext := authorize(policy.ModerateReview(actorOf(payload), listing)); ext != nil {
+		return nil, ext
+	}
+
+	review.IsPublished = false
+
+	review, err = s.reviewRepo.Update(review)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewResponse(200, review), nil
+}
+
+func (s *reviewService) FindAllForListing(listingId string, page string, limit string) (*utils.Pagination, *utils.AppError) {
+	listingIdInt, err := strconv.Atoi(listingId)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt <= 0 {
+		pageInt = 1
+	}
+
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt <= 0 {
+		limitInt = 20
+	}
+
+	reviews, totalItems, totalPage, err := s.reviewRepo.FindAllForListing(listingIdInt, pageInt, limitInt)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	if err := fillReviewAuthors(s.userRepo, reviews); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return utils.NewPaginationResponse(totalItems, totalPage, pageInt, limitInt, reviews), nil
+}
+
+func (s *reviewService) findReview(id string) (*domain.Review, *utils.AppError) {
+	idInt, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil, utils.NewAppError(400, "Invalid input")
+	}
+
+	review, err := s.reviewRepo.FindOne(idInt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Review not found!")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, err.Error())
+	}
+
+	return review, nil
+}
+
+// fillReviewAuthors attaches the public profile of each review's author,
+// loading all of them at once.
+func fillReviewAuthors(repo storage.UserRepository, reviews []*domain.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(reviews))
+
+	for _, review := range reviews {
+		ids = append(ids, review.AuthorID)
+	}
+
+	authors, err := repo.FindAuthors(ids)
+
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[int]*domain.Author, len(authors))
+
+	for _, author := range authors {
+		byId[author.ID] = author
+	}
+
+	for _, review := range reviews {
+		review.Author = byId[review.AuthorID]
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/may20xx/booking/internal/database"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+var (
+	revocations     storage.RevocationStore
+	revocationsOnce sync.Once
+)
+
+// Revocations returns the access token denylist shared by the services and
+// AuthGuard. It lives in Redis when configured and in memory otherwise.
+func Revocations() storage.RevocationStore {
+	revocationsOnce.Do(func() {
+		if client := database.GetRedis(); client != nil {
+			revocations = storage.NewRedisRevocationStore(client, context.Background())
+			return
+		}
+		revocations = storage.NewMemoryRevocationStore()
+	})
+	return revocations
+}
+
+// IsRevoked reports whether an access token was revoked before it expired.
+func IsRevoked(payload *utils.JwtPayload) (bool, error) {
+	return Revocations().IsRevoked(storage.TokenClaims{
+		Jti:       payload.Jti,
+		SessionID: payload.Sid,
+		UserID:    payload.Sub,
+		IssuedAt:  time.Unix(payload.Iat, 0),
+	})
+}
+
+// revokeAccessTokens denies the access tokens still in flight for the given
+// sessions. Entries only need to outlive the tokens, hence AccessTokenTTL.
+func revokeAccessTokens(store storage.RevocationStore, sessionIds ...int) *utils.AppError {
+	for _, id := range sessionIds {
+		if err := store.RevokeSession(id, utils.AccessTokenTTL); err != nil {
+			log.Msg.Error(err)
+			return utils.NewAppError(500, "Internal server error")
+		}
+	}
+
+	return nil
+}
+
+// revokeUserAccessTokens denies every access token issued to the user so far.
+func revokeUserAccessTokens(store storage.RevocationStore, userId int) *utils.AppError {
+	if err := store.RevokeUser(userId, time.Now(), utils.AccessTokenTTL); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+const (
+	defaultSearchLimit     = 20
+	defaultSuggestionLimit = 10
+	maxSuggestionLimit     = 20
+	// minSuggestionLength is how many characters are needed before there is
+	// anything similar enough to suggest.
+	minSuggestionLength = 2
+	// defaultSearchRadius is how far, in km, a search around a point reaches
+	// when no radius is given.
+	defaultSearchRadius = 10
+)
+
+func (s *listingService) Search(req *dto.ListingSearchRequest) (*utils.Pagination, *utils.AppError) {
+	filter, ext := listingSearchOf(req)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	limit := searchLimit(req)
+
+	listings, totalItems, totalPage, err := s.listingRepo.Search(filter, page, limit)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if ext := s.withLandlordAndPhotos(listings); ext != nil {
+		return nil, ext
+	}
+
+	return utils.NewPaginationResponse(totalItems, totalPage, page, limit, listings), nil
+}
+
+// SearchAfter is Search paged by cursor, which stays stable while listings
+// are added or removed between requests.
+func (s *listingService) SearchAfter(req *dto.ListingSearchRequest) (*utils.CursorPagination, *utils.AppError) {
+	filter, ext := listingSearchOf(req)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	limit := searchLimit(req)
+
+	listings, next, err := s.listingRepo.SearchAfter(filter, req.Cursor, limit)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			return nil, utils.NewAppError(400, "Invalid cursor")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if ext := s.withLandlordAndPhotos(listings); ext != nil {
+		return nil, ext
+	}
+
+	return utils.NewCursorPaginationResponse(next, limit, listings), nil
+}
+
+// Suggest autocompletes a search from a few typed characters.
+func (s *listingService) Suggest(text string, limit string) (*utils.Response, *utils.AppError) {
+	text = strings.TrimSpace(text)
+
+	if utf8.RuneCountInString(text) < minSuggestionLength {
+		return utils.NewResponse(200, []*domain.Suggestion{}), nil
+	}
+
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt <= 0 {
+		limitInt = defaultSuggestionLimit
+	}
+
+	if limitInt > maxSuggestionLimit {
+		limitInt = maxSuggestionLimit
+	}
+
+	suggestions, err := s.listingRepo.Suggest(text, limitInt)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if suggestions == nil {
+		suggestions = []*domain.Suggestion{}
+	}
+
+	return utils.NewResponse(200, suggestions), nil
+}
+
+// Clusters groups the listings a search would find inside a map view into
+// pins sized for the zoom level.
+func (s *listingService) Clusters(req *dto.ListingSearchRequest) (*utils.Response, *utils.AppError) {
+	filter, ext := listingSearchOf(req)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	if filter.Bounds == nil {
+		return nil, utils.NewAppError(400, "A bounding box is required")
+	}
+
+	clusters, err := s.listingRepo.Clusters(filter, domain.ClusterCellSize(req.Zoom))
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if clusters == nil {
+		clusters = []*domain.Cluster{}
+	}
+
+	return utils.NewResponse(200, clusters), nil
+}
+
+func searchLimit(req *dto.ListingSearchRequest) int {
+	if req.Limit <= 0 {
+		return defaultSearchLimit
+	}
+	return req.Limit
+}
+
+func listingSearchOf(req *dto.ListingSearchRequest) (*domain.ListingSearch, *utils.AppError) {
+	filter := &domain.ListingSearch{
+		Text:     strings.TrimSpace(req.Text),
+		Location: strings.TrimSpace(req.Query),
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+		Guests:   req.Guests,
+		Beds:     req.Beds,
+		Baths:    req.Baths,
+		Sort:     domain.ListingSort(req.Sort),
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = domain.SortNewest
+
+		if filter.Text != "" {
+			filter.Sort = domain.SortRelevance
+		}
+	}
+
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return nil, utils.NewAppError(400, "Minimum price must not exceed the maximum price")
+	}
+
+	if req.Catalogs != "" {
+		for _, value := range strings.Split(req.Catalogs, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(value))
+
+			if err != nil || id <= 0 {
+				return nil, utils.NewAppError(400, "Invalid catalogs")
+			}
+
+			filter.CatalogIDs = append(filter.CatalogIDs, id)
+		}
+	}
+
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return nil, utils.NewAppError(400, "Latitude and longitude must be given together")
+	}
+
+	if req.Latitude != nil {
+		filter.Near = &domain.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+		filter.RadiusKm = req.Radius
+
+		if !filter.Near.Valid() {
+			return nil, utils.NewAppError(400, "Invalid coordinates")
+		}
+
+		if filter.RadiusKm == 0 {
+			filter.RadiusKm = defaultSearchRadius
+		}
+	} else if filter.Sort == domain.SortDistance {
+		return nil, utils.NewAppError(400, "Sorting by distance needs a latitude and longitude")
+	}
+
+	if req.Bounds != "" {
+		bounds, ok := parseBounds(req.Bounds)
+
+		if !ok {
+			return nil, utils.NewAppError(400, "Invalid bounding box")
+		}
+
+		filter.Bounds = bounds
+	}
+
+	if req.Start != "" || req.End != "" {
+		checkIn, checkOut, ext := parseStay(req.Start, req.End)
+
+		if ext != nil {
+			return nil, ext
+		}
+
+		filter.CheckIn = &checkIn
+		filter.CheckOut = &checkOut
+	}
+
+	return filter, nil
+}
+
+// parseBounds reads a bounding box given as "south,west,north,east".
+func parseBounds(value string) (*domain.BoundingBox, bool) {
+	parts := strings.Split(value, ",")
+
+	if len(parts) != 4 {
+		return nil, false
+	}
+
+	var edges [4]float64
+
+	for i, part := range parts {
+		edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+
+		if err != nil {
+			return nil, false
+		}
+
+		edges[i] = edge
+	}
+
+	bounds := &domain.BoundingBox{South: edges[0], West: edges[1], North: edges[2], East: edges[3]}
+
+	return bounds, bounds.Valid()
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+	"github.com/may20xx/booking/pkg/secret"
+)
+
+// issueToken stores the hash of a new single-use token of the given type and
+// returns the token itself so it can be mailed to the user.
+func issueToken(repo storage.TokenStorage, user *domain.User, name string, ttl time.Duration) (string, *utils.AppError) {
+	token, hash, err := secret.NewToken()
+
+	if err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(500, "Error generating token")
+	}
+
+	expirationTime := time.Now().Add(ttl)
+
+	newToken := &domain.Token{
+		UserID:    user.ID,
+		Name:      name,
+		Token:     hash,
+		ExpiredAt: &expirationTime,
+	}
+
+	if _, err = repo.Insert(newToken); err != nil {
+		log.Msg.Error(err)
+		return "", utils.NewAppError(500, "Error saving token")
+	}
+
+	return token, nil
+}
+
+// consumeToken looks up a single-use token of the given type and marks it as
+// used. Each token is accepted at most once, even under concurrent requests.
+func consumeToken(repo storage.TokenStorage, token string, name string) (*domain.Token, *utils.AppError) {
+	existingToken, ext := findToken(repo, token, name)
+
+	if ext != nil {
+		return nil, ext
+	}
+
+	used, err := repo.Revoke(existingToken.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if !used {
+		return nil, utils.NewAppError(400, "Token has already been used")
+	}
+
+	return existingToken, nil
+}
+
+// findToken looks up a single-use token of the given type that is neither
+// used nor expired, without using it up.
+func findToken(repo storage.TokenStorage, token string, name string) (*domain.Token, *utils.AppError) {
+	existingToken, err := repo.FindOneByValue(secret.Hash(token))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewAppError(404, "Token not found")
+		}
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	if existingToken.Name != name {
+		return nil, utils.NewAppError(404, "Token not found")
+	}
+
+	if existingToken.RevokedAt != nil {
+		return nil, utils.NewAppError(400, "Token has already been used")
+	}
+
+	if existingToken.ExpiredAt != nil && existingToken.ExpiredAt.Before(time.Now()) {
+		return nil, utils.NewAppError(401, "Token expired")
+	}
+
+	return existingToken, nil
+}
+
+// revokeSessions signs the user out of every session but exceptSessionId,
+// or of all of them when it is 0, along with their refresh tokens and the
+// access tokens issued for them.
+func revokeSessions(sessionRepo storage.SessionRepository, tokenRepo storage.TokenStorage, revocations storage.RevocationStore, userId int, exceptSessionId int) *utils.AppError {
+	sessions, err := sessionRepo.FindAllForUser(userId)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	if err := sessionRepo.RevokeAllForUser(userId, exceptSessionId); err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	if exceptSessionId == 0 {
+		err = tokenRepo.RevokeAllForUser(userId, dto.RefreshToken)
+	} else {
+		err = tokenRepo.RevokeAllForUserExcept(userId, dto.RefreshToken, exceptSessionId)
+	}
+
+	if err != nil {
+		log.Msg.Error(err)
+		return utils.NewAppError(500, "Internal server error")
+	}
+
+	if exceptSessionId == 0 {
+		return revokeUserAccessTokens(revocations, userId)
+	}
+
+	var sessionIds []int
+
+	for _, session := range sessions {
+		if session.ID != exceptSessionId {
+			sessionIds = append(sessionIds, session.ID)
+		}
+	}
+
+	return revokeAccessTokens(revocations, sessionIds...)
+}
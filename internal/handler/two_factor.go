@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/may20xx/booking/internal/storage"
+	"github.com/may20xx/booking/internal/utils"
+	"github.com/may20xx/booking/pkg/log"
+	"github.com/may20xx/booking/pkg/secret"
+	"github.com/may20xx/booking/pkg/totp"
+)
+
+const (
+	twoFactorIssuer   = "Booking"
+	twoFactorTokenTTL = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// verifySecondFactor accepts either a current TOTP code or one of the
+// user's unused recovery codes. Both are single use.
+func verifySecondFactor(userRepo storage.UserRepository, tokenRepo storage.TokenStorage, twoFactor *domain.TwoFactor, code string) (bool, *utils.AppError) {
+	if twoFactor.Secret == nil {
+		return false, nil
+	}
+
+	if counter, ok := totp.Verify(*twoFactor.Secret, code, time.Now()); ok {
+		used, err := userRepo.UseTwoFactorCounter(twoFactor.UserID, counter)
+
+		if err != nil {
+			log.Msg.Error(err)
+			return false, utils.NewAppError(500, "Internal server error")
+		}
+
+		return used, nil
+	}
+
+	recoveryCode, err := tokenRepo.FindOneByValue(secret.Hash(totp.NormalizeRecoveryCode(code)))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		log.Msg.Error(err)
+		return false, utils.NewAppError(500, "Internal server error")
+	}
+
+	if recoveryCode.Name != dto.RecoveryCode || recoveryCode.UserID != twoFactor.UserID || recoveryCode.RevokedAt != nil {
+		return false, nil
+	}
+
+	used, err := tokenRepo.Revoke(recoveryCode.ID)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return false, utils.NewAppError(500, "Internal server error")
+	}
+
+	return used, nil
+}
+
+// issueRecoveryCodes replaces the user's recovery codes and returns the new
+// ones. Only their hashes are kept, so they are shown once.
+func issueRecoveryCodes(tokenRepo storage.TokenStorage, userId int) ([]string, *utils.AppError) {
+	codes, err := totp.NewRecoveryCodes(recoveryCodeCount)
+
+	if err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Error generating recovery codes")
+	}
+
+	if err := tokenRepo.RevokeAllForUser(userId, dto.RecoveryCode); err != nil {
+		log.Msg.Error(err)
+		return nil, utils.NewAppError(500, "Internal server error")
+	}
+
+	for _, code := range codes {
+		_, err := tokenRepo.Insert(&domain.Token{
+			UserID: userId,
+			Name:   dto.RecoveryCode,
+			Token:  secret.Hash(code),
+		})
+
+		if err != nil {
+			log.Msg.Error(err)
+			return nil, utils.NewAppError(500, "Error saving recovery codes")
+		}
+	}
+
+	return codes, nil
+}
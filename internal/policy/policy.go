@@ -0,0 +1,66 @@
+// Package policy decides whether an authenticated user may act on a
+// resource. Ownership is checked against the JWT subject and admins bypass
+// every ownership rule.
+package policy
+
+import (
+	"errors"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/samber/lo"
+)
+
+// ErrForbidden is returned when the actor does not own the resource.
+var ErrForbidden = errors.New("access denied")
+
+// Actor is the user a decision is made for, as identified by the JWT.
+type Actor struct {
+	ID    int
+	Roles []string
+}
+
+func (a Actor) IsAdmin() bool {
+	return lo.Contains(a.Roles, dto.Admin)
+}
+
+// Owns allows the actor when it is one of owners or an admin.
+func Owns(actor Actor, owners ...int) error {
+	if actor.IsAdmin() || lo.Contains(owners, actor.ID) {
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// ManageListing covers updating and removing a listing, its photos and its
+// cancellation policy, as well as listing its bookings.
+func ManageListing(actor Actor, listing *domain.Listing) error {
+	return Owns(actor, listing.LandlordID)
+}
+
+// ViewBooking allows the guest and the landlord of the booked listing.
+func ViewBooking(actor Actor, booking *domain.Booking, listing *domain.Listing) error {
+	return Owns(actor, booking.GuestID, listing.LandlordID)
+}
+
+// ManageBooking covers the host side of a booking: accept, decline and
+// complete.
+func ManageBooking(actor Actor, listing *domain.Listing) error {
+	return Owns(actor, listing.LandlordID)
+}
+
+// CancelBooking covers the guest side of a booking.
+func CancelBooking(actor Actor, booking *domain.Booking) error {
+	return Owns(actor, booking.GuestID)
+}
+
+// EditReview allows the author of the review.
+func EditReview(actor Actor, review *domain.Review) error {
+	return Owns(actor, review.AuthorID)
+}
+
+// ModerateReview allows the landlord of the reviewed listing.
+func ModerateReview(actor Actor, listing *domain.Listing) error {
+	return Owns(actor, listing.LandlordID)
+}
@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/may20xx/booking/internal/api/dto"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwns(t *testing.T) {
+	assert.NoError(t, Owns(Actor{ID: 1}, 1))
+	assert.NoError(t, Owns(Actor{ID: 2}, 1, 2))
+	assert.ErrorIs(t, Owns(Actor{ID: 3}, 1, 2), ErrForbidden)
+	assert.ErrorIs(t, Owns(Actor{ID: 3, Roles: []string{dto.Manager}}, 1), ErrForbidden)
+	assert.NoError(t, Owns(Actor{ID: 3, Roles: []string{dto.Admin}}, 1))
+}
+
+func TestViewBooking(t *testing.T) {
+	booking := &domain.Booking{GuestID: 1}
+	listing := &domain.Listing{LandlordID: 2}
+
+	assert.NoError(t, ViewBooking(Actor{ID: 1}, booking, listing))
+	assert.NoError(t, ViewBooking(Actor{ID: 2}, booking, listing))
+	assert.ErrorIs(t, ViewBooking(Actor{ID: 3}, booking, listing), ErrForbidden)
+}
+
+func TestManageBooking(t *testing.T) {
+	listing := &domain.Listing{LandlordID: 2}
+
+	assert.NoError(t, ManageBooking(Actor{ID: 2}, listing))
+	assert.ErrorIs(t, ManageBooking(Actor{ID: 1}, listing), ErrForbidden)
+	assert.ErrorIs(t, CancelBooking(Actor{ID: 2}, &domain.Booking{GuestID: 1}), ErrForbidden)
+}
+
+func TestReviews(t *testing.T) {
+	review := &domain.Review{AuthorID: 1}
+	listing := &domain.Listing{LandlordID: 2}
+
+	assert.NoError(t, EditReview(Actor{ID: 1}, review))
+	assert.ErrorIs(t, EditReview(Actor{ID: 2}, review), ErrForbidden)
+	assert.NoError(t, ModerateReview(Actor{ID: 2}, listing))
+	assert.ErrorIs(t, ModerateReview(Actor{ID: 1}, listing), ErrForbidden)
+	assert.NoError(t, ModerateReview(Actor{ID: 9, Roles: []string{dto.Admin}}, listing))
+}
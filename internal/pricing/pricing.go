@@ -0,0 +1,124 @@
+package pricing
+
+import (
+	"math"
+	"time"
+
+	"github.com/may20xx/booking/internal/domain"
+)
+
+// Nights returns the number of nights between check-in and check-out.
+func Nights(startDate time.Time, endDate time.Time) int {
+	return int(endDate.Sub(startDate).Hours() / 24)
+}
+
+// Quote computes what a guest pays for staying at the listing between
+// startDate and endDate. Each night is priced on its own by the listing's
+// price rules and itemized in the detail. Cleaning fee, service fee and
+// taxes are flat amounts charged once per stay on top of the nights.
+func Quote(listing *domain.Listing, startDate time.Time, endDate time.Time) *domain.PriceDetail {
+	nights := Nights(startDate, endDate)
+	weekendPercent := percentOf(listing.PriceRules, domain.RuleWeekend)
+	discountPercent := stayDiscount(listing.PriceRules, nights)
+
+	detail := &domain.PriceDetail{
+		CleaningFee: round(listing.CleaningFee),
+		ServiceFee:  round(listing.ServiceFee),
+		Taxes:       round(listing.Taxes),
+	}
+
+	totalHomePrice := 0.0
+
+	for i := 0; i < nights; i++ {
+		night := &domain.NightlyPrice{Date: startDate.AddDate(0, 0, i)}
+		night.BasePrice = round(nightlyRate(listing, night.Date))
+
+		if isWeekend(night.Date) {
+			night.WeekendSurcharge = round(night.BasePrice * weekendPercent / 100)
+		}
+
+		night.Discount = round((night.BasePrice + night.WeekendSurcharge) * discountPercent / 100)
+		night.Price = round(night.BasePrice + night.WeekendSurcharge - night.Discount)
+
+		detail.Nights = append(detail.Nights, night)
+		totalHomePrice += night.Price
+	}
+
+	detail.TotalHomePrice = round(totalHomePrice)
+	detail.TotalPrice = round(detail.TotalHomePrice + detail.CleaningFee + detail.ServiceFee + detail.Taxes)
+
+	return detail
+}
+
+// nightlyRate is the price of the night starting on date before weekend
+// and length-of-stay adjustments: the season's price when one covers it,
+// the listing's price otherwise.
+func nightlyRate(listing *domain.Listing, date time.Time) float64 {
+	for _, rule := range listing.PriceRules {
+		if rule.Kind != domain.RuleSeason || rule.Price == nil || rule.StartDate == nil || rule.EndDate == nil {
+			continue
+		}
+
+		if !date.Before(*rule.StartDate) && date.Before(*rule.EndDate) {
+			return *rule.Price
+		}
+	}
+
+	return listing.Price
+}
+
+// isWeekend reports whether the night starting on date is a Friday or a
+// Saturday night.
+func isWeekend(date time.Time) bool {
+	return date.Weekday() == time.Friday || date.Weekday() == time.Saturday
+}
+
+// stayDiscount is the percentage taken off every night of a stay of the
+// given length. A monthly discount wins over a weekly one.
+func stayDiscount(rules []*domain.PriceRule, nights int) float64 {
+	if nights >= domain.MonthlyStayNights {
+		if percent := percentOf(rules, domain.RuleMonthly); percent > 0 {
+			return percent
+		}
+	}
+
+	if nights >= domain.WeeklyStayNights {
+		return percentOf(rules, domain.RuleWeekly)
+	}
+
+	return 0
+}
+
+func percentOf(rules []*domain.PriceRule, kind domain.PriceRuleKind) float64 {
+	for _, rule := range rules {
+		if rule.Kind == kind && rule.Percent != nil {
+			return *rule.Percent
+		}
+	}
+
+	return 0
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// RefundPercent returns the share of the stay refunded by policy when the
+// booking starting on startDate is cancelled at cancelledAt.
+func RefundPercent(policy *domain.CancellationPolicy, startDate time.Time, cancelledAt time.Time) int {
+	daysBefore := int(math.Floor(startDate.Sub(cancelledAt).Hours() / 24))
+
+	if daysBefore >= policy.CutoffDays {
+		return policy.RefundPercent
+	}
+
+	return policy.LateRefundPercent
+}
+
+// Refund returns the amount given back to the guest out of the stored price
+// detail when the booking is cancelled at cancelledAt.
+func Refund(policy *domain.CancellationPolicy, detail *domain.PriceDetail, startDate time.Time, cancelledAt time.Time) float64 {
+	percent := RefundPercent(policy, startDate, cancelledAt)
+
+	return round(detail.TotalPrice * float64(percent) / 100)
+}
@@ -0,0 +1,108 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNights(t *testing.T) {
+	startDate := time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 1, Nights(startDate, startDate.AddDate(0, 0, 1)))
+	assert.Equal(t, 7, Nights(startDate, startDate.AddDate(0, 0, 7)))
+}
+
+func TestQuote(t *testing.T) {
+	listing := &domain.Listing{
+		Price:       120.5,
+		CleaningFee: 30,
+		ServiceFee:  15.25,
+		Taxes:       9.99,
+	}
+
+	startDate := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 3)
+
+	detail := Quote(listing, startDate, endDate)
+
+	assert.Equal(t, 361.5, detail.TotalHomePrice)
+	assert.Equal(t, 30.0, detail.CleaningFee)
+	assert.Equal(t, 15.25, detail.ServiceFee)
+	assert.Equal(t, 9.99, detail.Taxes)
+	assert.Equal(t, 416.74, detail.TotalPrice)
+}
+
+func TestQuote_PriceRules(t *testing.T) {
+	weekend, weekly, monthly := 20.0, 10.0, 25.0
+	seasonPrice := 150.0
+	seasonStart := time.Date(2025, 2, 8, 0, 0, 0, 0, time.UTC)
+	seasonEnd := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	listing := &domain.Listing{
+		Price:       100,
+		CleaningFee: 30,
+		PriceRules: []*domain.PriceRule{
+			{Kind: domain.RuleWeekend, Percent: &weekend},
+			{Kind: domain.RuleSeason, Price: &seasonPrice, StartDate: &seasonStart, EndDate: &seasonEnd},
+			{Kind: domain.RuleWeekly, Percent: &weekly},
+			{Kind: domain.RuleMonthly, Percent: &monthly},
+		},
+	}
+
+	// Wednesday 5 February to Wednesday 12 February: seven nights, with a
+	// regular and a seasonal weekend night.
+	startDate := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+
+	detail := Quote(listing, startDate, startDate.AddDate(0, 0, 7))
+
+	prices := make([]float64, 0, len(detail.Nights))
+	for _, night := range detail.Nights {
+		prices = append(prices, night.Price)
+	}
+
+	assert.Equal(t, []float64{90, 90, 108, 162, 135, 90, 90}, prices)
+	assert.Equal(t, 20.0, detail.Nights[2].WeekendSurcharge)
+	assert.Equal(t, 150.0, detail.Nights[3].BasePrice)
+	assert.Equal(t, 18.0, detail.Nights[3].Discount)
+	assert.Equal(t, 765.0, detail.TotalHomePrice)
+	assert.Equal(t, 795.0, detail.TotalPrice)
+
+	short := Quote(listing, startDate, startDate.AddDate(0, 0, 3))
+
+	assert.Equal(t, 320.0, short.TotalHomePrice)
+
+	long := Quote(listing, startDate, startDate.AddDate(0, 0, 28))
+
+	assert.Equal(t, 30.0, long.Nights[2].Discount)
+}
+
+func TestRefund(t *testing.T) {
+	policy, _ := domain.DefaultCancellationPolicy(domain.PolicyModerate)
+	detail := &domain.PriceDetail{TotalPrice: 401}
+	startDate := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		cancelledAt time.Time
+		want        float64
+	}{
+		{time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC), 401},
+		{time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), 401},
+		{time.Date(2025, 2, 5, 0, 0, 1, 0, time.UTC), 200.5},
+		{time.Date(2025, 2, 12, 0, 0, 0, 0, time.UTC), 200.5},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, Refund(policy, detail, startDate, c.cancelledAt), c.cancelledAt.String())
+	}
+}
+
+func TestRefundPercent_CustomPolicy(t *testing.T) {
+	policy := &domain.CancellationPolicy{CutoffDays: 0, RefundPercent: 80, LateRefundPercent: 10}
+	startDate := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 80, RefundPercent(policy, startDate, startDate))
+	assert.Equal(t, 10, RefundPercent(policy, startDate, startDate.Add(time.Hour)))
+}
@@ -25,10 +25,20 @@ func init() {
 		log.Msg.Panic(err)
 	}
 
+	if err := database.InitRedis(); err != nil {
+		log.Msg.Panic(err)
+	}
+
+	if err := utils.InitKeyring(); err != nil {
+		log.Msg.Panic(err)
+	}
+
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(utils.NewAppError(fiber.StatusOK, "Hello World!"))
 	})
 
+	router.WellKnownRouter(app)
+
 	v1 := app.Group("/api/v1")
 
 	router.InitRouter(v1)
@@ -40,4 +50,5 @@ func init() {
 	app.Listen(":" + config.Port)
 
 	defer database.CloseDatabase()
+	defer database.CloseRedis()
 }
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+// ErrBlockOverlapsBooking is returned when the nights to block are already
+// taken by a pending or confirmed booking.
+var ErrBlockOverlapsBooking = errors.New("blocked dates overlap an active booking")
+
+// ErrBlockedDatesConflict is returned when the blocked dates of the listing
+// were changed at the same time by someone else.
+var ErrBlockedDatesConflict = errors.New("blocked dates were changed concurrently")
+
+type BlockedDateRepository interface {
+	FindAllForListing(listingId int, from time.Time, to time.Time) ([]*domain.BlockedDate, error)
+	Block(listingId int, startDate time.Time, endDate time.Time) (*domain.BlockedDate, error)
+	Unblock(listingId int, startDate time.Time, endDate time.Time) error
+}
+
+type blockedDateRepository struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func NewBlockedDateRepository(db *sqlx.DB, ctx context.Context) *blockedDateRepository {
+	return &blockedDateRepository{db: db, ctx: ctx}
+}
+
+// FindAllForListing returns the blocked ranges that overlap [from, to).
+func (r *blockedDateRepository) FindAllForListing(listingId int, from time.Time, to time.Time) ([]*domain.BlockedDate, error) {
+	query := `
+		SELECT id, listing_id, start_date, end_date, created_at
+		FROM listing_blocked_dates
+		WHERE listing_id = $1 AND start_date < $3 AND end_date > $2
+		ORDER BY start_date ASC
+	`
+
+	var blocked []*domain.BlockedDate
+
+	if err := r.db.SelectContext(r.ctx, &blocked, query, listingId, from, to); err != nil {
+		return nil, fmt.Errorf("error fetching blocked dates for listing: %w", err)
+	}
+
+	return blocked, nil
+}
+
+// Block takes [startDate, endDate) off the market. Ranges it overlaps or
+// touches are merged into it, so a listing's blocks never overlap. Nights
+// already booked cannot be blocked.
+func (r *blockedDateRepository) Block(listingId int, startDate time.Time, endDate time.Time) (*domain.BlockedDate, error) {
+	tx, err := r.db.BeginTxx(r.ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockListing(r.ctx, tx, listingId); err != nil {
+		return nil, err
+	}
+
+	var booked bool
+
+	err = tx.GetContext(r.ctx, &booked, `
+		SELECT EXISTS(SELECT 1 FROM bookings WHERE listing_id = $1 AND start_date < $3 AND end_date > $2 AND status IN ('pending', 'confirmed'))
+	`, listingId, startDate, endDate)
+
+	if err != nil {
+		return nil, fmt.Errorf("error checking bookings: %w", err)
+	}
+
+	if booked {
+		return nil, ErrBlockOverlapsBooking
+	}
+
+	removed, err := r.remove(tx, "start_date <= $3 AND end_date >= $2", listingId, startDate, endDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block := &domain.BlockedDate{ListingID: listingId, StartDate: startDate, EndDate: endDate}
+
+	for _, old := range removed {
+		if old.StartDate.Before(block.StartDate) {
+			block.StartDate = old.StartDate
+		}
+		if old.EndDate.After(block.EndDate) {
+			block.EndDate = old.EndDate
+		}
+	}
+
+	if err := r.insert(tx, block); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing blocked dates: %w", err)
+	}
+
+	return block, nil
+}
+
+// Unblock puts [startDate, endDate) back on the market. A block that only
+// partly overlaps the range keeps the nights outside it.
+func (r *blockedDateRepository) Unblock(listingId int, startDate time.Time, endDate time.Time) error {
+	tx, err := r.db.BeginTxx(r.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockListing(r.ctx, tx, listingId); err != nil {
+		return err
+	}
+
+	removed, err := r.remove(tx, "start_date < $3 AND end_date > $2", listingId, startDate, endDate)
+
+	if err != nil {
+		return err
+	}
+
+	for _, old := range removed {
+		if old.StartDate.Before(startDate) {
+			if err := r.insert(tx, &domain.BlockedDate{ListingID: listingId, StartDate: old.StartDate, EndDate: startDate}); err != nil {
+				return err
+			}
+		}
+		if old.EndDate.After(endDate) {
+			if err := r.insert(tx, &domain.BlockedDate{ListingID: listingId, StartDate: endDate, EndDate: old.EndDate}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing blocked dates: %w", err)
+	}
+
+	return nil
+}
+
+// remove deletes the listing's blocks matching condition, which compares
+// against the range in $2 and $3, and returns what was deleted.
+func (r *blockedDateRepository) remove(tx *sqlx.Tx, condition string, listingId int, startDate time.Time, endDate time.Time) ([]*domain.BlockedDate, error) {
+	query := "DELETE FROM listing_blocked_dates WHERE listing_id = $1 AND " + condition + " RETURNING id, listing_id, start_date, end_date, created_at"
+
+	var removed []*domain.BlockedDate
+
+	if err := tx.SelectContext(r.ctx, &removed, query, listingId, startDate, endDate); err != nil {
+		return nil, fmt.Errorf("error removing blocked dates: %w", err)
+	}
+
+	return removed, nil
+}
+
+func (r *blockedDateRepository) insert(tx *sqlx.Tx, block *domain.BlockedDate) error {
+	query := `
+		INSERT INTO listing_blocked_dates (listing_id, start_date, end_date, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := tx.QueryRowxContext(r.ctx, query, block.ListingID, block.StartDate, block.EndDate, time.Now()).
+		Scan(&block.ID, &block.CreatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == exclusionViolation {
+			return ErrBlockedDatesConflict
+		}
+		return fmt.Errorf("error saving blocked dates: %w", err)
+	}
+
+	return nil
+}
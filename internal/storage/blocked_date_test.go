@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var blockedDateColumns = []string{"id", "listing_id", "start_date", "end_date", "created_at"}
+
+func TestBlockedDateStorage_BlockMerges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBlockedDateRepository(sqlxDB, context.Background())
+
+	startDate := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 3)
+	earlier := startDate.AddDate(0, 0, -2)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM listings WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM bookings`).
+		WithArgs(1, startDate, endDate).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`DELETE FROM listing_blocked_dates WHERE listing_id = \$1 AND start_date <= \$3 AND end_date >= \$2 RETURNING`).
+		WithArgs(1, startDate, endDate).
+		WillReturnRows(sqlmock.NewRows(blockedDateColumns).
+			AddRow(3, 1, earlier, startDate, now).
+			AddRow(4, 1, startDate.AddDate(0, 0, 1), startDate.AddDate(0, 0, 2), now))
+	mock.ExpectQuery(`INSERT INTO listing_blocked_dates`).
+		WithArgs(1, earlier, endDate, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, now))
+	mock.ExpectCommit()
+
+	block, err := repo.Block(1, startDate, endDate)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, block.ID)
+	assert.Equal(t, earlier, block.StartDate)
+	assert.Equal(t, endDate, block.EndDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBlockedDateStorage_BlockOverlapsBooking(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBlockedDateRepository(sqlxDB, context.Background())
+
+	startDate := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 3)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM listings WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM bookings`).
+		WithArgs(1, startDate, endDate).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	block, err := repo.Block(1, startDate, endDate)
+
+	assert.Nil(t, block)
+	assert.ErrorIs(t, err, ErrBlockOverlapsBooking)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBlockedDateStorage_UnblockSplits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBlockedDateRepository(sqlxDB, context.Background())
+
+	blockStart := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	blockEnd := blockStart.AddDate(0, 0, 10)
+	startDate := blockStart.AddDate(0, 0, 3)
+	endDate := blockStart.AddDate(0, 0, 5)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM listings WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`DELETE FROM listing_blocked_dates WHERE listing_id = \$1 AND start_date < \$3 AND end_date > \$2 RETURNING`).
+		WithArgs(1, startDate, endDate).
+		WillReturnRows(sqlmock.NewRows(blockedDateColumns).AddRow(3, 1, blockStart, blockEnd, now))
+	mock.ExpectQuery(`INSERT INTO listing_blocked_dates`).
+		WithArgs(1, blockStart, startDate, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(6, now))
+	mock.ExpectQuery(`INSERT INTO listing_blocked_dates`).
+		WithArgs(1, endDate, blockEnd, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, now))
+	mock.ExpectCommit()
+
+	err = repo.Unblock(1, startDate, endDate)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,19 +3,46 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/may20xx/booking/internal/domain"
 )
 
+// ErrBookingConflict is returned when the requested nights overlap another
+// booking of the same listing.
+var ErrBookingConflict = errors.New("booking overlaps an existing booking")
+
+// ErrBookingStatusChanged is returned when a booking's status was changed by
+// someone else between reading and updating it.
+var ErrBookingStatusChanged = errors.New("booking status has changed")
+
+const exclusionViolation = "23P01"
+
+// lockListing takes a row lock on the listing for the rest of tx, so that
+// bookings and blocked dates of one listing are written one at a time and
+// each writer sees what the one before it committed.
+func lockListing(ctx context.Context, tx *sqlx.Tx, listingId int) error {
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM listings WHERE id = $1 FOR UPDATE", listingId); err != nil {
+		return fmt.Errorf("error locking listing: %w", err)
+	}
+	return nil
+}
+
 type BookingRepository interface {
 	Save(req *domain.Booking) (*domain.Booking, error)
 	FindDetail(id int) (*domain.Booking, error)
 	FindAllForListing(listingId int, page int, limit int) ([]*domain.Booking, int, int, error)
 	FindAllForUser(userId int, page int, limit int) ([]*domain.Booking, int, int, error)
+	FindAllForGuest(userId int) ([]*domain.Booking, error)
+	ExistActiveForUser(userId int) (bool, error)
 	ExistBooking(listingId int, startDate time.Time, endDate time.Time) (bool, error)
+	FindActiveForListing(listingId int, from time.Time, to time.Time) ([]*domain.Booking, error)
+	FindPriceDetail(bookingId int) (*domain.PriceDetail, error)
+	UpdateStatus(booking *domain.Booking, status domain.BookingStatus) (*domain.Booking, error)
 }
 
 type bookingRepository struct {
@@ -31,7 +58,7 @@ func (r *bookingRepository) FindAllForListing(listingId, page, limit int) ([]*do
 	offset := (page - 1) * limit
 
 	query := `
-		SELECT id, listing_id, user_id, start_date, end_date, guest, nights, phone_number, created_at, updated_at
+		SELECT id, listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at
 		FROM bookings
 		WHERE listing_id = $1
 		ORDER BY created_at DESC
@@ -60,9 +87,9 @@ func (r *bookingRepository) FindAllForUser(userId, page, limit int) ([]*domain.B
 	offset := (page - 1) * limit
 
 	query := `
-		SELECT id, listing_id, user_id, start_date, end_date, guest, nights, phone_number, created_at, updated_at
+		SELECT id, listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at
 		FROM bookings
-		WHERE user_id = $1
+		WHERE guest_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -73,7 +100,7 @@ func (r *bookingRepository) FindAllForUser(userId, page, limit int) ([]*domain.B
 		return nil, 0, 0, fmt.Errorf("error fetching bookings for user: %w", err)
 	}
 
-	totalQuery := "SELECT COUNT(*) FROM bookings WHERE user_id = $1"
+	totalQuery := "SELECT COUNT(*) FROM bookings WHERE guest_id = $1"
 	var totalBookings int
 	err = r.db.GetContext(r.ctx, &totalBookings, totalQuery, userId)
 	if err != nil {
@@ -85,32 +112,95 @@ func (r *bookingRepository) FindAllForUser(userId, page, limit int) ([]*domain.B
 	return bookings, totalBookings, totalPages, nil
 }
 
+// FindAllForGuest returns every booking the user made, oldest first.
+func (r *bookingRepository) FindAllForGuest(userId int) ([]*domain.Booking, error) {
+	query := `
+		SELECT id, listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at
+		FROM bookings
+		WHERE guest_id = $1
+		ORDER BY created_at ASC
+	`
+
+	var bookings []*domain.Booking
+
+	if err := r.db.SelectContext(r.ctx, &bookings, query, userId); err != nil {
+		return nil, fmt.Errorf("error fetching bookings for guest: %w", err)
+	}
+
+	return bookings, nil
+}
+
+// ExistActiveForUser reports whether the user has a pending or confirmed
+// booking that has not ended yet, either as the guest or as the host.
+func (r *bookingRepository) ExistActiveForUser(userId int) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM bookings b
+			JOIN listings l ON l.id = b.listing_id
+			WHERE (b.guest_id = $1 OR l.landlord_id = $1) AND b.status IN ('pending', 'confirmed') AND b.end_date >= CURRENT_DATE
+		)
+	`
+
+	var exists bool
+
+	if err := r.db.GetContext(r.ctx, &exists, query, userId); err != nil {
+		return false, fmt.Errorf("error checking for active bookings: %w", err)
+	}
+
+	return exists, nil
+}
+
 func (r *bookingRepository) FindDetail(id int) (*domain.Booking, error) {
-	var booking *domain.Booking
+	var booking domain.Booking
 
-	query := `SELECT id, listing_id, user_id, start_date, end_date, guest, nights, phone_number, message_to_host, created_at, updated_at FROM bookings WHERE id = $1`
+	query := `SELECT id, listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at FROM bookings WHERE id = $1`
 
 	if err := r.db.GetContext(r.ctx, &booking, query, id); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("booking with id %d not found", id)
+			return nil, fmt.Errorf("booking with id %d not found: %w", id, err)
 		}
 		return nil, fmt.Errorf("error finding booking: %w", err)
 	}
 
-	return booking, nil
+	return &booking, nil
 }
 
 func (r *bookingRepository) Save(booking *domain.Booking) (*domain.Booking, error) {
 	query := `
-		INSERT INTO bookings (listing_id, user_id, start_date, end_date, guest, nights, phone_number, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO bookings (listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
 	now := time.Now()
+	booking.Status = domain.BookingPending
 	booking.CreatedAt = now
 	booking.UpdatedAt = now
 
-	err := r.db.QueryRowxContext(
+	tx, err := r.db.BeginTxx(r.ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockListing(r.ctx, tx, booking.ListingID); err != nil {
+		return nil, err
+	}
+
+	var blocked bool
+
+	err = tx.GetContext(r.ctx, &blocked, `
+		SELECT EXISTS(SELECT 1 FROM listing_blocked_dates WHERE listing_id = $1 AND start_date < $3 AND end_date > $2)
+	`, booking.ListingID, booking.StartDate, booking.EndDate)
+
+	if err != nil {
+		return nil, fmt.Errorf("error checking blocked dates: %w", err)
+	}
+
+	if blocked {
+		return nil, ErrBookingConflict
+	}
+
+	err = tx.QueryRowxContext(
 		r.ctx,
 		query,
 		booking.ListingID,
@@ -120,19 +210,115 @@ func (r *bookingRepository) Save(booking *domain.Booking) (*domain.Booking, erro
 		booking.Guests,
 		booking.Nights,
 		booking.PhoneNumber,
+		booking.MessageToHost,
+		booking.Status,
 		booking.CreatedAt,
 		booking.UpdatedAt,
 	).Scan(&booking.ID, &booking.CreatedAt, &booking.UpdatedAt)
 
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == exclusionViolation {
+			return nil, ErrBookingConflict
+		}
 		return nil, fmt.Errorf("error saving booking: %w", err)
 	}
 
+	if booking.PriceDetail != nil {
+		if err := r.savePriceDetail(tx, booking); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing booking: %w", err)
+	}
+
 	return booking, nil
 }
 
+func (r *bookingRepository) savePriceDetail(tx *sqlx.Tx, booking *domain.Booking) error {
+	query := `
+		INSERT INTO price_details (booking_id, total_home_price, cleaning_fee, service_fee, taxes, total_price, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	detail := booking.PriceDetail
+	detail.BookingID = booking.ID
+	detail.CreatedAt = booking.CreatedAt
+
+	err := tx.QueryRowxContext(r.ctx, query,
+		detail.BookingID,
+		detail.TotalHomePrice,
+		detail.CleaningFee,
+		detail.ServiceFee,
+		detail.Taxes,
+		detail.TotalPrice,
+		detail.CreatedAt,
+	).Scan(&detail.ID, &detail.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving price detail: %w", err)
+	}
+
+	if len(detail.Nights) == 0 {
+		return nil
+	}
+
+	for _, night := range detail.Nights {
+		night.PriceDetailID = detail.ID
+	}
+
+	nightsQuery := `
+		INSERT INTO price_detail_nights (price_detail_id, night, base_price, weekend_surcharge, discount, price)
+		VALUES (:price_detail_id, :night, :base_price, :weekend_surcharge, :discount, :price)
+	`
+
+	if _, err := tx.NamedExecContext(r.ctx, nightsQuery, detail.Nights); err != nil {
+		return fmt.Errorf("error saving nightly prices: %w", err)
+	}
+
+	return nil
+}
+
+func (r *bookingRepository) FindPriceDetail(bookingId int) (*domain.PriceDetail, error) {
+	var detail domain.PriceDetail
+
+	query := `
+		SELECT id, booking_id, total_home_price, cleaning_fee, service_fee, taxes, total_price, created_at
+		FROM price_details
+		WHERE booking_id = $1
+	`
+
+	if err := r.db.GetContext(r.ctx, &detail, query, bookingId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("price detail for booking with id %d not found: %w", bookingId, err)
+		}
+		return nil, fmt.Errorf("error finding price detail: %w", err)
+	}
+
+	nightsQuery := `
+		SELECT id, price_detail_id, night, base_price, weekend_surcharge, discount, price
+		FROM price_detail_nights
+		WHERE price_detail_id = $1
+		ORDER BY night ASC
+	`
+
+	if err := r.db.SelectContext(r.ctx, &detail.Nights, nightsQuery, detail.ID); err != nil {
+		return nil, fmt.Errorf("error finding nightly prices: %w", err)
+	}
+
+	return &detail, nil
+}
+
+// ExistBooking reports whether any night of [startDate, endDate) is taken,
+// either by a booking or by the host blocking it.
 func (r *bookingRepository) ExistBooking(listingId int, startDate time.Time, endDate time.Time) (bool, error) {
-	query := "SELECT EXISTS(SELECT 1 FROM bookings WHERE listing_id = $1 AND start_date <= $2 AND end_date >= $3)"
+	query := `
+		SELECT EXISTS(SELECT 1 FROM bookings WHERE listing_id = $1 AND start_date < $3 AND end_date > $2 AND status IN ('pending', 'confirmed'))
+			OR EXISTS(SELECT 1 FROM listing_blocked_dates WHERE listing_id = $1 AND start_date < $3 AND end_date > $2)
+	`
 	var exists bool
 	err := r.db.GetContext(r.ctx, &exists, query, listingId, startDate, endDate)
 	if err != nil {
@@ -140,3 +326,44 @@ func (r *bookingRepository) ExistBooking(listingId int, startDate time.Time, end
 	}
 	return exists, nil
 }
+
+// FindActiveForListing returns the pending and confirmed bookings of the
+// listing that hold a night of [from, to).
+func (r *bookingRepository) FindActiveForListing(listingId int, from time.Time, to time.Time) ([]*domain.Booking, error) {
+	query := `
+		SELECT id, listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at
+		FROM bookings
+		WHERE listing_id = $1 AND start_date < $3 AND end_date > $2 AND status IN ('pending', 'confirmed')
+		ORDER BY start_date ASC
+	`
+
+	var bookings []*domain.Booking
+
+	if err := r.db.SelectContext(r.ctx, &bookings, query, listingId, from, to); err != nil {
+		return nil, fmt.Errorf("error fetching active bookings for listing: %w", err)
+	}
+
+	return bookings, nil
+}
+
+func (r *bookingRepository) UpdateStatus(booking *domain.Booking, status domain.BookingStatus) (*domain.Booking, error) {
+	query := `
+		UPDATE bookings
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowxContext(r.ctx, query, status, time.Now(), booking.ID, booking.Status).Scan(&booking.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBookingStatusChanged
+		}
+		return nil, fmt.Errorf("error updating booking status: %w", err)
+	}
+
+	booking.Status = status
+
+	return booking, nil
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingStorage_Save(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBookingRepository(sqlxDB, context.Background())
+
+	startDate := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 3)
+
+	booking := &domain.Booking{
+		ListingID: 1,
+		GuestID:   2,
+		Guests:    2,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Nights:    3,
+		PriceDetail: &domain.PriceDetail{
+			TotalHomePrice: 300,
+			CleaningFee:    20,
+			ServiceFee:     10,
+			Taxes:          5,
+			TotalPrice:     335,
+			Nights: []*domain.NightlyPrice{
+				{Date: startDate, BasePrice: 100, Price: 100},
+				{Date: startDate.AddDate(0, 0, 1), BasePrice: 100, Price: 100},
+				{Date: startDate.AddDate(0, 0, 2), BasePrice: 100, Price: 100},
+			},
+		},
+	}
+
+	query := `
+		INSERT INTO bookings \(listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at\)
+		VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11\)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM listings WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM listing_blocked_dates`).
+		WithArgs(1, startDate, booking.EndDate).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(query).
+		WithArgs(
+			booking.ListingID,
+			booking.GuestID,
+			booking.StartDate,
+			booking.EndDate,
+			booking.Guests,
+			booking.Nights,
+			booking.PhoneNumber,
+			booking.MessageToHost,
+			domain.BookingPending,
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, now, now))
+	mock.ExpectQuery(`INSERT INTO price_details`).
+		WithArgs(1, 300.0, 20.0, 10.0, 5.0, 335.0, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, now))
+	mock.ExpectExec(`INSERT INTO price_detail_nights \(price_detail_id, night, base_price, weekend_surcharge, discount, price\) VALUES \(.+\),\(.+\),\(.+\)`).
+		WithArgs(
+			7, startDate, 100.0, 0.0, 0.0, 100.0,
+			7, startDate.AddDate(0, 0, 1), 100.0, 0.0, 0.0, 100.0,
+			7, startDate.AddDate(0, 0, 2), 100.0, 0.0, 0.0, 100.0,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	savedBooking, err := repo.Save(booking)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, savedBooking.ID)
+	assert.Equal(t, 7, savedBooking.PriceDetail.ID)
+	assert.Equal(t, 1, savedBooking.PriceDetail.BookingID)
+	assert.Equal(t, 7, savedBooking.PriceDetail.Nights[2].PriceDetailID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookingStorage_FindDetailNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBookingRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, listing_id, guest_id, start_date, end_date, guests, nights, phone_number, message_to_host, status, created_at, updated_at FROM bookings WHERE id = \$1`
+
+	mock.ExpectQuery(query).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+	booking, err := repo.FindDetail(1)
+
+	assert.Nil(t, booking)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookingStorage_SaveConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBookingRepository(sqlxDB, context.Background())
+
+	startDate := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	booking := &domain.Booking{
+		ListingID: 1,
+		GuestID:   2,
+		Guests:    1,
+		StartDate: startDate,
+		EndDate:   startDate.AddDate(0, 0, 2),
+		Nights:    2,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM listings WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM listing_blocked_dates`).
+		WithArgs(1, startDate, booking.EndDate).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO bookings`).
+		WillReturnError(&pq.Error{Code: "23P01", Constraint: "bookings_no_overlap"})
+	mock.ExpectRollback()
+
+	savedBooking, err := repo.Save(booking)
+
+	assert.Nil(t, savedBooking)
+	assert.ErrorIs(t, err, ErrBookingConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookingStorage_ExistBooking(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBookingRepository(sqlxDB, context.Background())
+
+	startDate := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 2)
+
+	query := `SELECT EXISTS\(SELECT 1 FROM bookings WHERE listing_id = \$1 AND start_date < \$3 AND end_date > \$2 AND status IN \('pending', 'confirmed'\)\)
+			OR EXISTS\(SELECT 1 FROM listing_blocked_dates WHERE listing_id = \$1 AND start_date < \$3 AND end_date > \$2\)`
+
+	mock.ExpectQuery(query).
+		WithArgs(1, startDate, endDate).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := repo.ExistBooking(1, startDate, endDate)
+
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookingStorage_UpdateStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewBookingRepository(sqlxDB, context.Background())
+
+	query := `UPDATE bookings SET status = \$1, updated_at = \$2 WHERE id = \$3 AND status = \$4 RETURNING updated_at`
+
+	mock.ExpectQuery(query).
+		WithArgs(domain.BookingConfirmed, sqlmock.AnyArg(), 1, domain.BookingPending).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+
+	booking, err := repo.UpdateStatus(&domain.Booking{ID: 1, Status: domain.BookingPending}, domain.BookingConfirmed)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingConfirmed, booking.Status)
+
+	mock.ExpectQuery(query).
+		WithArgs(domain.BookingCancelled, sqlmock.AnyArg(), 1, domain.BookingPending).
+		WillReturnError(sql.ErrNoRows)
+
+	booking, err = repo.UpdateStatus(&domain.Booking{ID: 1, Status: domain.BookingPending}, domain.BookingCancelled)
+
+	assert.Nil(t, booking)
+	assert.ErrorIs(t, err, ErrBookingStatusChanged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
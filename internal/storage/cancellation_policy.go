@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+type CancellationPolicyRepository interface {
+	FindForListing(listingId int) (*domain.CancellationPolicy, error)
+	Upsert(policy *domain.CancellationPolicy) (*domain.CancellationPolicy, error)
+}
+
+type cancellationPolicyRepository struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func NewCancellationPolicyRepository(db *sqlx.DB, ctx context.Context) *cancellationPolicyRepository {
+	return &cancellationPolicyRepository{db: db, ctx: ctx}
+}
+
+func (r *cancellationPolicyRepository) FindForListing(listingId int) (*domain.CancellationPolicy, error) {
+	var policy domain.CancellationPolicy
+
+	query := `
+		SELECT listing_id, name, cutoff_days, refund_percent, late_refund_percent, created_at, updated_at
+		FROM cancellation_policies
+		WHERE listing_id = $1
+	`
+
+	if err := r.db.GetContext(r.ctx, &policy, query, listingId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("cancellation policy for listing with id %d not found: %w", listingId, err)
+		}
+		return nil, fmt.Errorf("error finding cancellation policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (r *cancellationPolicyRepository) Upsert(policy *domain.CancellationPolicy) (*domain.CancellationPolicy, error) {
+	query := `
+		INSERT INTO cancellation_policies (listing_id, name, cutoff_days, refund_percent, late_refund_percent, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (listing_id) DO UPDATE
+		SET name = EXCLUDED.name, cutoff_days = EXCLUDED.cutoff_days, refund_percent = EXCLUDED.refund_percent,
+			late_refund_percent = EXCLUDED.late_refund_percent, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	now := time.Now()
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		policy.ListingID,
+		policy.Name,
+		policy.CutoffDays,
+		policy.RefundPercent,
+		policy.LateRefundPercent,
+		now,
+		now,
+	).Scan(&policy.CreatedAt, &policy.UpdatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("error saving cancellation policy: %w", err)
+	}
+
+	return policy, nil
+}
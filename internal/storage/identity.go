@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+var ErrIdentityLinked = errors.New("identity is already linked to an account")
+
+type IdentityRepository interface {
+	Save(identity *domain.UserIdentity) (*domain.UserIdentity, error)
+	FindOne(provider string, subject string) (*domain.UserIdentity, error)
+	FindAllForUser(userId int) ([]*domain.UserIdentity, error)
+}
+
+type identityRepository struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func NewIdentityRepository(db *sqlx.DB, ctx context.Context) *identityRepository {
+	return &identityRepository{db: db, ctx: ctx}
+}
+
+func (r *identityRepository) Save(identity *domain.UserIdentity) (*domain.UserIdentity, error) {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		time.Now(),
+	).Scan(&identity.ID, &identity.CreatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil, ErrIdentityLinked
+		}
+		return nil, fmt.Errorf("error saving identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+func (r *identityRepository) FindOne(provider string, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	if err := r.db.GetContext(r.ctx, &identity, query, provider, subject); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("identity %s at %s not found: %w", subject, provider, err)
+		}
+		return nil, fmt.Errorf("error finding identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+func (r *identityRepository) FindAllForUser(userId int) ([]*domain.UserIdentity, error) {
+	var identities []*domain.UserIdentity
+
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	if err := r.db.SelectContext(r.ctx, &identities, query, userId); err != nil {
+		return nil, fmt.Errorf("error finding identities for user: %w", err)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityStorage_Save(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewIdentityRepository(sqlxDB, context.Background())
+
+	email := "alice@example.com"
+	identity := &domain.UserIdentity{UserID: 1, Provider: "google", Subject: "42", Email: &email}
+
+	query := `INSERT INTO user_identities \(user_id, provider, subject, email, created_at\)`
+
+	mock.ExpectQuery(query).
+		WithArgs(1, "google", "42", &email, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+
+	saved, err := repo.Save(identity)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, saved.ID)
+
+	mock.ExpectQuery(query).
+		WillReturnError(&pq.Error{Code: uniqueViolation, Constraint: "unique_identity_subject"})
+
+	saved, err = repo.Save(&domain.UserIdentity{UserID: 2, Provider: "google", Subject: "42"})
+
+	assert.Nil(t, saved)
+	assert.ErrorIs(t, err, ErrIdentityLinked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdentityStorage_FindOne(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewIdentityRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE provider = \$1 AND subject = \$2`
+
+	mock.ExpectQuery(query).
+		WithArgs("github", "1234").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email", "created_at"}).
+			AddRow(1, 7, "github", "1234", nil, time.Now()))
+
+	identity, err := repo.FindOne("github", "1234")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, identity.UserID)
+	assert.Nil(t, identity.Email)
+
+	mock.ExpectQuery(query).WithArgs("github", "5678").WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.FindOne("github", "5678")
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -16,7 +16,11 @@ type ListingRepository interface {
 	Save(listing *domain.Listing) (*domain.Listing, error)
 	Update(id int, listing *domain.Listing) (*domain.Listing, error)
 	Remove(id int) error
-	SearchByLocation(page int, limit int, location string) ([]*domain.Listing, int, int, error)
+	Search(filter *domain.ListingSearch, page int, limit int) ([]*domain.Listing, int, int, error)
+	SearchAfter(filter *domain.ListingSearch, cursor string, limit int) ([]*domain.Listing, string, error)
+	Suggest(text string, limit int) ([]*domain.Suggestion, error)
+	Clusters(filter *domain.ListingSearch, cellSize float64) ([]*domain.Cluster, error)
+	FindAllForLandlord(landlordId int) ([]*domain.Listing, error)
 }
 
 type listingRepository struct {
@@ -34,8 +38,9 @@ func (r *listingRepository) FindAll(page int, limit int) ([]*domain.Listing, int
 	var total int
 
 	query := `
-		SELECT id, title, description, location, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, created_at, updated_at
+		SELECT id, title, description, location, latitude, longitude, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, created_at, updated_at
 		FROM listings
+		WHERE archived_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -46,7 +51,7 @@ func (r *listingRepository) FindAll(page int, limit int) ([]*domain.Listing, int
 		return nil, 0, 0, fmt.Errorf("error finding listings: %w", err)
 	}
 
-	totalQuery := "SELECT COUNT(*) FROM listings"
+	totalQuery := "SELECT COUNT(*) FROM listings WHERE archived_at IS NULL"
 	err = r.db.GetContext(r.ctx, &total, totalQuery)
 
 	if err != nil {
@@ -60,7 +65,7 @@ func (r *listingRepository) FindAll(page int, limit int) ([]*domain.Listing, int
 
 func (r *listingRepository) FindOne(id int) (*domain.Listing, error) {
 	query := `
-		SELECT id, title, description, location, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, created_at, updated_at
+		SELECT id, title, description, location, latitude, longitude, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, archived_at, created_at, updated_at
 		FROM listings
 		WHERE id = $1
 	`
@@ -71,7 +76,7 @@ func (r *listingRepository) FindOne(id int) (*domain.Listing, error) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("listing with id %d not found", id)
+			return nil, fmt.Errorf("listing with id %d not found: %w", id, err)
 		}
 		return nil, fmt.Errorf("error finding listing: %w", err)
 	}
@@ -82,8 +87,8 @@ func (r *listingRepository) FindOne(id int) (*domain.Listing, error) {
 func (r *listingRepository) Update(id int, listing *domain.Listing) (*domain.Listing, error) {
 	query := `
 		UPDATE listings
-		SET title = $1, description = $2, location = $3, guests = $4, beds = $5, baths = $6, price = $7, cleaning_fee = $8, service_fee = $9, taxes = $10, landlord_id = $11, updated_at = $12
-		WHERE id = $13
+		SET title = $1, description = $2, location = $3, latitude = $4, longitude = $5, guests = $6, beds = $7, baths = $8, price = $9, cleaning_fee = $10, service_fee = $11, taxes = $12, landlord_id = $13, updated_at = $14
+		WHERE id = $15
 		RETURNING id, updated_at
 		`
 	now := time.Now()
@@ -93,6 +98,8 @@ func (r *listingRepository) Update(id int, listing *domain.Listing) (*domain.Lis
 		listing.Title,
 		listing.Description,
 		listing.Location,
+		listing.Latitude,
+		listing.Longitude,
 		listing.Guests,
 		listing.Beds,
 		listing.Baths,
@@ -126,8 +133,8 @@ func (r *listingRepository) Remove(id int) error {
 
 func (r *listingRepository) Save(listing *domain.Listing) (*domain.Listing, error) {
 	query := `
-		INSERT INTO listings (title, description, location, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO listings (title, description, location, latitude, longitude, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -139,6 +146,8 @@ func (r *listingRepository) Save(listing *domain.Listing) (*domain.Listing, erro
 		listing.Title,
 		listing.Description,
 		listing.Location,
+		listing.Latitude,
+		listing.Longitude,
 		listing.Guests,
 		listing.Beds,
 		listing.Baths,
@@ -158,34 +167,21 @@ func (r *listingRepository) Save(listing *domain.Listing) (*domain.Listing, erro
 	return listing, nil
 }
 
-func (r *listingRepository) SearchByLocation(page int, limit int, location string) ([]*domain.Listing, int, int, error) {
+// FindAllForLandlord returns every listing of the landlord, archived ones
+// included.
+func (r *listingRepository) FindAllForLandlord(landlordId int) ([]*domain.Listing, error) {
 	var listings []*domain.Listing
-	var total int
 
 	query := `
-        SELECT id, title, description, location, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, created_at, updated_at
-        FROM listings
-        WHERE location LIKE '%' || $1 || '%'
-        ORDER BY created_at DESC
-        LIMIT $2 OFFSET $3
-    `
-
-	err := r.db.SelectContext(r.ctx, &listings, query, location, limit, (page-1)*limit)
-	if err != nil {
-		return nil, 0, 0, fmt.Errorf("error finding listings: %w", err)
-	}
+		SELECT id, title, description, location, latitude, longitude, guests, beds, baths, price, cleaning_fee, service_fee, taxes, landlord_id, archived_at, created_at, updated_at
+		FROM listings
+		WHERE landlord_id = $1
+		ORDER BY created_at ASC
+	`
 
-	totalQuery := `
-        SELECT COUNT(*)
-        FROM listings
-        WHERE location LIKE '%' || $1 || '%'
-    `
-	err = r.db.GetContext(r.ctx, &total, totalQuery, location)
-	if err != nil {
-		return nil, 0, 0, fmt.Errorf("error counting listings: %w", err)
+	if err := r.db.SelectContext(r.ctx, &listings, query, landlordId); err != nil {
+		return nil, fmt.Errorf("error finding listings for landlord: %w", err)
 	}
 
-	totalPage := (total + limit - 1) / limit
-
-	return listings, total, totalPage, nil
+	return listings, nil
 }
@@ -0,0 +1,547 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const listingSearchColumns = `
+	l.id, l.title, l.description, l.location, l.latitude, l.longitude, l.guests, l.beds, l.baths, l.price, l.cleaning_fee, l.service_fee, l.taxes,
+	l.landlord_id, l.archived_at, l.created_at, l.updated_at,
+	COALESCE(r.rating, 0) AS rating, COALESCE(r.review_count, 0) AS review_count
+`
+
+// snippetOptions highlight matches in at most two short fragments of the
+// description.
+const snippetOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// escapedDescription is the description with HTML special characters
+// escaped, so the snippet only ever carries the <mark> tags added to it.
+const escapedDescription = `replace(replace(replace(replace(l.description, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`
+
+// phraseSnippetLength is how many characters of the description are shown
+// around a phrase when the search has no words for ts_headline to find.
+const phraseSnippetLength = 160
+
+const listingSearchFrom = `
+	FROM listings l
+	LEFT JOIN (
+		SELECT listing_id, ROUND(AVG(rating), 2) AS rating, COUNT(*) AS review_count
+		FROM reviews
+		WHERE is_published = TRUE
+		GROUP BY listing_id
+	) r ON r.listing_id = l.id
+`
+
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+type listingSearchRow struct {
+	domain.Listing
+	SearchRating      float64  `db:"rating"`
+	SearchReviewCount int      `db:"review_count"`
+	SearchRank        float64  `db:"rank"`
+	SearchSnippet     *string  `db:"snippet"`
+	SearchDistance    *float64 `db:"distance"`
+}
+
+// listingOrder is how a sort option orders the results: by key, then by id to
+// break ties, both in the same direction.
+type listingOrder struct {
+	key        string
+	descending bool
+}
+
+var listingOrders = map[domain.ListingSort]listingOrder{
+	domain.SortNewest:    {key: "l.created_at", descending: true},
+	domain.SortPriceAsc:  {key: "l.price"},
+	domain.SortPriceDesc: {key: "l.price", descending: true},
+	domain.SortRating:    {key: "COALESCE(r.rating, 0)", descending: true},
+}
+
+// sortOf is the sort a search is run with. Relevance needs search text,
+// distance needs a point to measure from, and anything unknown falls back to
+// the newest listings first.
+func sortOf(filter *domain.ListingSearch, q *listingQuery) domain.ListingSort {
+	if filter.Sort == domain.SortRelevance && q.hasText() {
+		return domain.SortRelevance
+	}
+	if filter.Sort == domain.SortDistance && q.near != nil {
+		return domain.SortDistance
+	}
+	if _, ok := listingOrders[filter.Sort]; ok {
+		return filter.Sort
+	}
+	return domain.SortNewest
+}
+
+func orderOf(sort domain.ListingSort, q *listingQuery) listingOrder {
+	if sort == domain.SortRelevance {
+		return listingOrder{key: q.rank(), descending: true}
+	}
+	if sort == domain.SortDistance {
+		return listingOrder{key: q.distance()}
+	}
+	return listingOrders[sort]
+}
+
+func (o listingOrder) clause() string {
+	direction := "ASC"
+	if o.descending {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s %s, l.id %s", o.key, direction, direction)
+}
+
+// listingCursor marks the last listing of a page. It remembers the sort it
+// was made for, since its value means nothing under another one.
+type listingCursor struct {
+	Sort  domain.ListingSort `json:"s"`
+	Value string             `json:"v"`
+	ID    int                `json:"id"`
+}
+
+func encodeCursor(sort domain.ListingSort, row *listingSearchRow) string {
+	cursor := listingCursor{Sort: sort, ID: row.ID}
+
+	switch sort {
+	case domain.SortPriceAsc, domain.SortPriceDesc:
+		cursor.Value = strconv.FormatFloat(row.Price, 'f', -1, 64)
+	case domain.SortRating:
+		cursor.Value = strconv.FormatFloat(row.SearchRating, 'f', -1, 64)
+	case domain.SortRelevance:
+		cursor.Value = strconv.FormatFloat(row.SearchRank, 'f', -1, 64)
+	case domain.SortDistance:
+		if row.SearchDistance != nil {
+			cursor.Value = strconv.FormatFloat(*row.SearchDistance, 'f', -1, 64)
+		}
+	default:
+		cursor.Value = row.CreatedAt.Format(timestampLayout)
+	}
+
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(sort domain.ListingSort, value string) (*listingCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor listingCursor
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	switch sort {
+	case domain.SortPriceAsc, domain.SortPriceDesc, domain.SortRating, domain.SortRelevance, domain.SortDistance:
+		_, err = strconv.ParseFloat(cursor.Value, 64)
+	default:
+		_, err = time.Parse(timestampLayout, cursor.Value)
+	}
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// listingQuery collects the conditions of a search along with their
+// positional arguments. text is the placeholder of the full-text query,
+// phrases those of the words matched as substrings, and near those of the
+// point distances are measured from, if there are any.
+type listingQuery struct {
+	conditions []string
+	args       []interface{}
+	text       string
+	phrases    []string
+	near       []string
+}
+
+func (q *listingQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *listingQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+func (q *listingQuery) clause() string {
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+func (q *listingQuery) hasText() bool {
+	return q.text != "" || len(q.phrases) > 0
+}
+
+// contains matches column, which must already be lowercased and unaccented,
+// against the phrase in placeholder.
+func contains(column string, placeholder string) string {
+	return fmt.Sprintf("%s LIKE '%%' || immutable_unaccent(%s) || '%%'", column, placeholder)
+}
+
+func (q *listingQuery) tsquery() string {
+	return fmt.Sprintf("to_tsquery('simple', immutable_unaccent(%s))", q.text)
+}
+
+// rank scores how well a listing matches. Phrases score like the weights of
+// search_vector: most in the title, less in the location, least elsewhere.
+func (q *listingQuery) rank() string {
+	var scores []string
+
+	if q.text != "" {
+		scores = append(scores, fmt.Sprintf("ts_rank(l.search_vector, %s)", q.tsquery()))
+	}
+
+	for _, phrase := range q.phrases {
+		scores = append(scores, fmt.Sprintf(
+			"(CASE WHEN %s THEN 1.0 WHEN %s THEN 0.4 ELSE 0.2 END)",
+			contains("immutable_unaccent(lower(l.title))", phrase),
+			contains("immutable_unaccent(lower(l.location))", phrase),
+		))
+	}
+
+	if len(scores) == 0 {
+		return "0"
+	}
+
+	return strings.Join(scores, " + ")
+}
+
+// snippet highlights the words the user typed, with or without accents, in
+// the HTML escaped description. ts_headline cannot find phrases inside a run
+// of text, so they are marked separately.
+func (q *listingQuery) snippet() string {
+	if !q.hasText() {
+		return "NULL"
+	}
+
+	var snippet string
+
+	if q.text != "" {
+		snippet = fmt.Sprintf("ts_headline('simple', %s, to_tsquery('simple', %s) || %s, '%s')", escapedDescription, q.text, q.tsquery(), snippetOptions)
+	} else {
+		snippet = fmt.Sprintf("substr(%s, greatest(1, strpos(lower(%s), %s) - %d), %d)", escapedDescription, escapedDescription, q.phrases[0], phraseSnippetLength/4, phraseSnippetLength)
+	}
+
+	for _, phrase := range q.phrases {
+		snippet = fmt.Sprintf("replace(%s, %s, '<mark>' || %s || '</mark>')", snippet, phrase, phrase)
+	}
+
+	return snippet
+}
+
+// distance is the haversine distance in kilometres from the searched point.
+func (q *listingQuery) distance() string {
+	if q.near == nil {
+		return "NULL"
+	}
+	lat, lng := q.near[0], q.near[1]
+	return fmt.Sprintf(
+		"(2 * 6371 * asin(least(1, sqrt(power(sin(radians(l.latitude - %s) / 2), 2) + cos(radians(%s)) * cos(radians(l.latitude)) * power(sin(radians(l.longitude - %s) / 2), 2)))))",
+		lat, lat, lng,
+	)
+}
+
+func (q *listingQuery) columns() string {
+	return fmt.Sprintf("%s, %s AS rank, %s AS snippet, %s AS distance", listingSearchColumns, q.rank(), q.snippet(), q.distance())
+}
+
+// within keeps the listings inside box, which wraps around when it crosses
+// the antimeridian.
+func (q *listingQuery) within(box domain.BoundingBox) {
+	q.where(fmt.Sprintf("l.latitude BETWEEN %s AND %s", q.arg(box.South), q.arg(box.North)))
+
+	if box.West <= box.East {
+		q.where(fmt.Sprintf("l.longitude BETWEEN %s AND %s", q.arg(box.West), q.arg(box.East)))
+	} else {
+		q.where(fmt.Sprintf("(l.longitude >= %s OR l.longitude <= %s)", q.arg(box.West), q.arg(box.East)))
+	}
+}
+
+// searchWords splits what the user typed into lowercase words, dropping
+// punctuation so that it cannot be read as tsquery syntax. Words with
+// Chinese or Japanese characters are returned as phrases: those scripts are
+// written without spaces, so the 'simple' configuration keeps a whole run of
+// them as one token and a word inside it can only be found as a substring.
+func searchWords(text string) (words []string, phrases []string) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, field := range fields {
+		field = strings.ToLower(field)
+
+		if strings.IndexFunc(field, isUnspaced) >= 0 {
+			phrases = append(phrases, field)
+		} else {
+			words = append(words, field)
+		}
+	}
+
+	return words, phrases
+}
+
+func isUnspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// prefixQuery turns words into a tsquery that matches listings containing
+// every word, the last ones possibly unfinished.
+func prefixQuery(words []string) string {
+	terms := make([]string, len(words))
+
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func newListingQuery(filter *domain.ListingSearch) *listingQuery {
+	q := &listingQuery{}
+
+	q.where("l.archived_at IS NULL")
+
+	words, phrases := searchWords(filter.Text)
+
+	if len(words) > 0 {
+		q.text = q.arg(prefixQuery(words))
+		q.where("l.search_vector @@ " + q.tsquery())
+	}
+
+	for _, phrase := range phrases {
+		placeholder := q.arg(phrase)
+		q.phrases = append(q.phrases, placeholder)
+		q.where(contains("l.search_text", placeholder))
+	}
+
+	if filter.Location != "" {
+		q.where(fmt.Sprintf("l.location ILIKE '%%' || %s || '%%'", q.arg(likeEscaper.Replace(filter.Location))))
+	}
+
+	if filter.MinPrice != nil {
+		q.where("l.price >= " + q.arg(*filter.MinPrice))
+	}
+
+	if filter.MaxPrice != nil {
+		q.where("l.price <= " + q.arg(*filter.MaxPrice))
+	}
+
+	if filter.Guests > 0 {
+		q.where("l.guests >= " + q.arg(filter.Guests))
+	}
+
+	if filter.Beds > 0 {
+		q.where("l.beds >= " + q.arg(filter.Beds))
+	}
+
+	if filter.Baths > 0 {
+		q.where("l.baths >= " + q.arg(filter.Baths))
+	}
+
+	if len(filter.CatalogIDs) > 0 {
+		q.where(fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM catalogs_listings cl WHERE cl.listing_id = l.id AND cl.catalog_id = ANY(%s))",
+			q.arg(pq.Array(filter.CatalogIDs)),
+		))
+	}
+
+	if filter.Near != nil && filter.RadiusKm > 0 {
+		q.within(filter.Near.Around(filter.RadiusKm))
+		q.near = []string{q.arg(filter.Near.Latitude), q.arg(filter.Near.Longitude)}
+		q.where(fmt.Sprintf("%s <= %s", q.distance(), q.arg(filter.RadiusKm)))
+	}
+
+	if filter.Bounds != nil {
+		q.within(*filter.Bounds)
+	}
+
+	if filter.CheckIn != nil && filter.CheckOut != nil {
+		checkOut, checkIn := q.arg(*filter.CheckOut), q.arg(*filter.CheckIn)
+
+		q.where(fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM bookings b WHERE b.listing_id = l.id AND b.start_date < %s AND b.end_date > %s AND b.status IN ('pending', 'confirmed'))",
+			checkOut,
+			checkIn,
+		))
+		q.where(fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM listing_blocked_dates d WHERE d.listing_id = l.id AND d.start_date < %s AND d.end_date > %s)",
+			checkOut,
+			checkIn,
+		))
+	}
+
+	return q
+}
+
+func toListings(rows []*listingSearchRow) []*domain.Listing {
+	listings := make([]*domain.Listing, 0, len(rows))
+
+	for _, row := range rows {
+		listing := row.Listing
+		listing.Rating = row.SearchRating
+		listing.ReviewCount = row.SearchReviewCount
+		listing.Snippet = row.SearchSnippet
+		listing.Distance = row.SearchDistance
+		listings = append(listings, &listing)
+	}
+
+	return listings
+}
+
+// Search returns a page of the listings matching filter, along with the
+// number of matches and pages.
+func (r *listingRepository) Search(filter *domain.ListingSearch, page int, limit int) ([]*domain.Listing, int, int, error) {
+	q := newListingQuery(filter)
+
+	var total int
+
+	totalQuery := "SELECT COUNT(*) FROM listings l " + q.clause()
+
+	if err := r.db.GetContext(r.ctx, &total, totalQuery, q.args...); err != nil {
+		return nil, 0, 0, fmt.Errorf("error counting listings: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s %s %s %s LIMIT %s OFFSET %s",
+		q.columns(),
+		listingSearchFrom,
+		q.clause(),
+		orderOf(sortOf(filter, q), q).clause(),
+		q.arg(limit),
+		q.arg((page-1)*limit),
+	)
+
+	var rows []*listingSearchRow
+
+	if err := r.db.SelectContext(r.ctx, &rows, query, q.args...); err != nil {
+		return nil, 0, 0, fmt.Errorf("error searching listings: %w", err)
+	}
+
+	totalPage := (total + limit - 1) / limit
+
+	return toListings(rows), total, totalPage, nil
+}
+
+// SearchAfter returns up to limit listings matching filter that come after
+// cursor in the sort order, starting from the top when cursor is empty. The
+// returned cursor leads to the next page and is empty on the last one.
+func (r *listingRepository) SearchAfter(filter *domain.ListingSearch, cursor string, limit int) ([]*domain.Listing, string, error) {
+	q := newListingQuery(filter)
+	sort := sortOf(filter, q)
+	order := orderOf(sort, q)
+
+	if cursor != "" {
+		after, err := decodeCursor(sort, cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		comparison := ">"
+		if order.descending {
+			comparison = "<"
+		}
+
+		q.where(fmt.Sprintf("(%s, l.id) %s (%s, %s)", order.key, comparison, q.arg(after.Value), q.arg(after.ID)))
+	}
+
+	query := fmt.Sprintf("SELECT %s %s %s %s LIMIT %s",
+		q.columns(),
+		listingSearchFrom,
+		q.clause(),
+		order.clause(),
+		q.arg(limit+1),
+	)
+
+	var rows []*listingSearchRow
+
+	if err := r.db.SelectContext(r.ctx, &rows, query, q.args...); err != nil {
+		return nil, "", fmt.Errorf("error searching listings: %w", err)
+	}
+
+	next := ""
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = encodeCursor(sort, rows[limit-1])
+	}
+
+	return toListings(rows), next, nil
+}
+
+// Suggest completes text with the titles and locations of listings that
+// contain a word similar to it, so that typos and missing accents still find
+// a match.
+func (r *listingRepository) Suggest(text string, limit int) ([]*domain.Suggestion, error) {
+	query := `
+		SELECT kind, text, listing_id FROM (
+			SELECT 'listing' AS kind, title AS text, id AS listing_id,
+				word_similarity(immutable_unaccent(lower($1)), immutable_unaccent(lower(title))) AS score
+			FROM listings
+			WHERE archived_at IS NULL AND immutable_unaccent(lower($1)) <% immutable_unaccent(lower(title))
+			UNION ALL
+			SELECT 'location', location, NULL,
+				word_similarity(immutable_unaccent(lower($1)), immutable_unaccent(lower(location)))
+			FROM listings
+			WHERE archived_at IS NULL AND immutable_unaccent(lower($1)) <% immutable_unaccent(lower(location))
+			GROUP BY location
+		) suggestions
+		ORDER BY score DESC, text
+		LIMIT $2
+	`
+
+	var suggestions []*domain.Suggestion
+
+	if err := r.db.SelectContext(r.ctx, &suggestions, query, text, limit); err != nil {
+		return nil, fmt.Errorf("error finding suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// Clusters groups the listings matching filter into the cells of a grid
+// cellSize degrees wide, one pin per cell, placed at the average position of
+// its listings.
+func (r *listingRepository) Clusters(filter *domain.ListingSearch, cellSize float64) ([]*domain.Cluster, error) {
+	q := newListingQuery(filter)
+
+	q.where("l.latitude IS NOT NULL AND l.longitude IS NOT NULL")
+
+	cell := q.arg(cellSize)
+
+	query := fmt.Sprintf(`
+		SELECT AVG(l.latitude) AS latitude, AVG(l.longitude) AS longitude, COUNT(*) AS count,
+			CASE WHEN COUNT(*) = 1 THEN MIN(l.id) END AS listing_id
+		FROM listings l
+		%s
+		GROUP BY floor(l.latitude / %s), floor(l.longitude / %s)
+		ORDER BY count DESC
+	`, q.clause(), cell, cell)
+
+	var clusters []*domain.Cluster
+
+	if err := r.db.SelectContext(r.ctx, &clusters, query, q.args...); err != nil {
+		return nil, fmt.Errorf("error clustering listings: %w", err)
+	}
+
+	return clusters, nil
+}
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var listingSearchRowColumns = []string{
+	"id", "title", "description", "location", "latitude", "longitude", "guests", "beds", "baths", "price", "cleaning_fee", "service_fee", "taxes",
+	"landlord_id", "archived_at", "created_at", "updated_at", "rating", "review_count",
+}
+
+// escapedDescriptionPattern matches escapedDescription in a query.
+var escapedDescriptionPattern = regexp.QuoteMeta(escapedDescription)
+
+func addListingSearchRow(rows *sqlmock.Rows, id int, price float64, createdAt time.Time) *sqlmock.Rows {
+	return rows.AddRow(id, "Title", "Description", "Hanoi", 21.0285, 105.8542, 2, 1, 1, price, 10.0, 5.0, 1.0, 3, nil, createdAt, createdAt, 4.5, 2)
+}
+
+func TestListingCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 1, 20, 10, 30, 0, 123456000, time.UTC)
+	row := &listingSearchRow{Listing: domain.Listing{ID: 7, Price: 120.5, CreatedAt: createdAt}}
+
+	cursor, err := decodeCursor(domain.SortNewest, encodeCursor(domain.SortNewest, row))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, cursor.ID)
+	assert.Equal(t, "2025-01-20 10:30:00.123456", cursor.Value)
+
+	cursor, err = decodeCursor(domain.SortPriceAsc, encodeCursor(domain.SortPriceAsc, row))
+	assert.NoError(t, err)
+	assert.Equal(t, "120.5", cursor.Value)
+
+	_, err = decodeCursor(domain.SortRating, encodeCursor(domain.SortPriceAsc, row))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = decodeCursor(domain.SortNewest, "not a cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestListingStorage_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	minPrice := 50.0
+	checkIn := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	checkOut := checkIn.AddDate(0, 0, 2)
+
+	filter := &domain.ListingSearch{
+		Location:   "100%",
+		MinPrice:   &minPrice,
+		Guests:     2,
+		CatalogIDs: []int{1, 4},
+		CheckIn:    &checkIn,
+		CheckOut:   &checkOut,
+		Sort:       domain.SortPriceAsc,
+	}
+
+	where := `WHERE l.archived_at IS NULL AND l.location ILIKE '%' \|\| \$1 \|\| '%' AND l.price >= \$2 AND l.guests >= \$3 ` +
+		`AND EXISTS \(SELECT 1 FROM catalogs_listings cl WHERE cl.listing_id = l.id AND cl.catalog_id = ANY\(\$4\)\) ` +
+		`AND NOT EXISTS \(SELECT 1 FROM bookings b WHERE b.listing_id = l.id AND b.start_date < \$5 AND b.end_date > \$6 AND b.status IN \('pending', 'confirmed'\)\) ` +
+		`AND NOT EXISTS \(SELECT 1 FROM listing_blocked_dates d WHERE d.listing_id = l.id AND d.start_date < \$5 AND d.end_date > \$6\)`
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM listings l `+where).
+		WithArgs(`100\%`, minPrice, 2, pq.Array([]int{1, 4}), checkOut, checkIn).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+	now := time.Now()
+	rows := addListingSearchRow(sqlmock.NewRows(listingSearchRowColumns), 1, 80, now)
+
+	mock.ExpectQuery(where+` ORDER BY l.price ASC, l.id ASC LIMIT \$7 OFFSET \$8`).
+		WithArgs(`100\%`, minPrice, 2, pq.Array([]int{1, 4}), checkOut, checkIn, 10, 10).
+		WillReturnRows(rows)
+
+	listings, total, totalPage, err := repo.Search(filter, 2, 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, listings, 1)
+	assert.Equal(t, 4.5, listings[0].Rating)
+	assert.Equal(t, 2, listings[0].ReviewCount)
+	assert.Equal(t, 21, total)
+	assert.Equal(t, 3, totalPage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListingStorage_SearchAfter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	filter := &domain.ListingSearch{Sort: domain.SortPriceDesc}
+	now := time.Now()
+
+	rows := sqlmock.NewRows(listingSearchRowColumns)
+	addListingSearchRow(rows, 3, 300, now)
+	addListingSearchRow(rows, 2, 200, now)
+	addListingSearchRow(rows, 1, 100, now)
+
+	mock.ExpectQuery(`WHERE l.archived_at IS NULL ORDER BY l.price DESC, l.id DESC LIMIT \$1`).
+		WithArgs(3).
+		WillReturnRows(rows)
+
+	listings, next, err := repo.SearchAfter(filter, "", 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, listings, 2)
+	assert.NotEmpty(t, next)
+
+	mock.ExpectQuery(`WHERE l.archived_at IS NULL AND \(l.price, l.id\) < \(\$1, \$2\) ORDER BY l.price DESC, l.id DESC LIMIT \$3`).
+		WithArgs("200", 2, 3).
+		WillReturnRows(addListingSearchRow(sqlmock.NewRows(listingSearchRowColumns), 1, 100, now))
+
+	listings, next, err = repo.SearchAfter(filter, next, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, listings, 1)
+	assert.Empty(t, next)
+
+	_, _, err = repo.SearchAfter(&domain.ListingSearch{Sort: domain.SortNewest}, encodeCursor(domain.SortPriceDesc, &listingSearchRow{Listing: domain.Listing{ID: 1}}), 2)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchWords(t *testing.T) {
+	words, phrases := searchWords("Hà Nội")
+	assert.Equal(t, "hà:* & nội:*", prefixQuery(words))
+	assert.Empty(t, phrases)
+
+	words, phrases = searchWords(" 東京, Apart")
+	assert.Equal(t, "apart:*", prefixQuery(words))
+	assert.Equal(t, []string{"東京"}, phrases)
+
+	words, _ = searchWords("beach' & !house:*")
+	assert.Equal(t, "beach:* & house:*", prefixQuery(words))
+
+	words, phrases = searchWords("&|!")
+	assert.Equal(t, "", prefixQuery(words))
+	assert.Empty(t, phrases)
+}
+
+func TestListingStorage_SearchText(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	filter := &domain.ListingSearch{Text: "Hà Nội", Sort: domain.SortRelevance}
+	tsquery := `to_tsquery\('simple', immutable_unaccent\(\$1\)\)`
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM listings l WHERE l.archived_at IS NULL AND l.search_vector @@ ` + tsquery).
+		WithArgs("hà:* & nội:*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	columns := append(listingSearchRowColumns, "rank", "snippet")
+	now := time.Now()
+
+	mock.ExpectQuery(`ts_rank\(l.search_vector, `+tsquery+`\) AS rank, ts_headline\('simple', `+escapedDescriptionPattern+`, `+
+		`to_tsquery\('simple', \$1\) \|\| `+tsquery+`, '.*'\) AS snippet, NULL AS distance .* `+
+		`ORDER BY ts_rank\(l.search_vector, `+tsquery+`\) DESC, l.id DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs("hà:* & nội:*", 20, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Nhà ở Hà Nội", "Gần Hồ Gươm", "Hà Nội", 21.0285, 105.8542, 2, 1, 1, 50.0, 0.0, 0.0, 0.0, 3, nil, now, now, 0, 0, 0.6, "Nhà ở <mark>Hà</mark> <mark>Nội</mark>"))
+
+	listings, total, _, err := repo.Search(filter, 1, 20)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "Nhà ở <mark>Hà</mark> <mark>Nội</mark>", *listings[0].Snippet)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListingStorage_SearchJapaneseText(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	// 東京 sits in the middle of the title, where the 'simple' configuration
+	// would only see the whole run 新宿の東京駅近くのアパート as one token.
+	filter := &domain.ListingSearch{Text: "東京", Sort: domain.SortRelevance}
+	inTitle := `immutable_unaccent\(lower\(l.title\)\) LIKE '%' \|\| immutable_unaccent\(\$1\) \|\| '%'`
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM listings l WHERE l.archived_at IS NULL AND l.search_text LIKE '%' \|\| immutable_unaccent\(\$1\) \|\| '%'`).
+		WithArgs("東京").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	columns := append(listingSearchRowColumns, "rank", "snippet")
+	now := time.Now()
+
+	mock.ExpectQuery(`\(CASE WHEN `+inTitle+` THEN 1.0 .* END\) AS rank, `+
+		`replace\(substr\(`+escapedDescriptionPattern+`, greatest\(1, strpos\(lower\(`+escapedDescriptionPattern+`\), \$1\) - 40\), 160\), \$1, '<mark>' \|\| \$1 \|\| '</mark>'\) AS snippet, `+
+		`NULL AS distance .* ORDER BY \(CASE WHEN `+inTitle+` .* END\) DESC, l.id DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs("東京", 20, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "新宿の東京駅近くのアパート", "東京駅まで徒歩5分", "東京都新宿区", 35.6812, 139.7671, 2, 1, 1, 90.0, 0.0, 0.0, 0.0, 3, nil, now, now, 0, 0, 1.0, "<mark>東京</mark>駅まで徒歩5分"))
+
+	listings, total, _, err := repo.Search(filter, 1, 20)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "新宿の東京駅近くのアパート", listings[0].Title)
+	assert.Equal(t, "<mark>東京</mark>駅まで徒歩5分", *listings[0].Snippet)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListingStorage_Suggest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	mock.ExpectQuery(`SELECT kind, text, listing_id FROM`).
+		WithArgs("hanoi", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "text", "listing_id"}).
+			AddRow("location", "Hà Nội", nil).
+			AddRow("listing", "Nhà ở Hà Nội", 1))
+
+	suggestions, err := repo.Suggest("hanoi", 5)
+
+	assert.NoError(t, err)
+	assert.Len(t, suggestions, 2)
+	assert.Nil(t, suggestions[0].ListingID)
+	assert.Equal(t, 1, *suggestions[1].ListingID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListingStorage_SearchNear(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	near := domain.GeoPoint{Latitude: 21.0285, Longitude: 105.8542}
+	box := near.Around(5)
+	filter := &domain.ListingSearch{Near: &near, RadiusKm: 5, Sort: domain.SortDistance}
+
+	distance := `\(2 \* 6371 \* asin\(least\(1, sqrt\(power\(sin\(radians\(l.latitude - \$5\) / 2\), 2\) \+ cos\(radians\(\$5\)\) \* ` +
+		`cos\(radians\(l.latitude\)\) \* power\(sin\(radians\(l.longitude - \$6\) / 2\), 2\)\)\)\)\)`
+
+	columns := append(listingSearchRowColumns, "rank", "snippet", "distance")
+	now := time.Now()
+
+	mock.ExpectQuery(distance+` AS distance .* WHERE l.archived_at IS NULL AND l.latitude BETWEEN \$1 AND \$2 AND l.longitude BETWEEN \$3 AND \$4 `+
+		`AND `+distance+` <= \$7 ORDER BY `+distance+` ASC, l.id ASC LIMIT \$8`).
+		WithArgs(box.South, box.North, box.West, box.East, near.Latitude, near.Longitude, 5.0, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Title", "Description", "Hà Nội", 21.03, 105.85, 2, 1, 1, 50.0, 0.0, 0.0, 0.0, 3, nil, now, now, 0, 0, 0, nil, 0.42).
+			AddRow(2, "Title", "Description", "Hà Nội", 21.04, 105.86, 2, 1, 1, 50.0, 0.0, 0.0, 0.0, 3, nil, now, now, 0, 0, 0, nil, 1.5))
+
+	listings, next, err := repo.SearchAfter(filter, "", 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, listings, 1)
+	assert.Equal(t, 0.42, *listings[0].Distance)
+
+	cursor, err := decodeCursor(domain.SortDistance, next)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.42", cursor.Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListingStorage_Clusters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewListingRepository(sqlxDB, context.Background())
+
+	bounds := domain.BoundingBox{South: -20, West: 170, North: -10, East: -170}
+	filter := &domain.ListingSearch{Bounds: &bounds}
+
+	mock.ExpectQuery(`WHERE l.archived_at IS NULL AND l.latitude BETWEEN \$1 AND \$2 AND \(l.longitude >= \$3 OR l.longitude <= \$4\) `+
+		`AND l.latitude IS NOT NULL AND l.longitude IS NOT NULL GROUP BY floor\(l.latitude / \$5\), floor\(l.longitude / \$5\)`).
+		WithArgs(-20.0, -10.0, 170.0, -170.0, 1.40625).
+		WillReturnRows(sqlmock.NewRows([]string{"latitude", "longitude", "count", "listing_id"}).
+			AddRow(-17.7, 178.1, 12, nil).
+			AddRow(-13.8, -171.8, 1, 9))
+
+	clusters, err := repo.Clusters(filter, domain.ClusterCellSize(5))
+
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, 12, clusters[0].Count)
+	assert.Nil(t, clusters[0].ListingID)
+	assert.Equal(t, 9, *clusters[1].ListingID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginAttemptStore counts failed sign-ins per key, an account or a client
+// address. Counts expire ttl after the last failure.
+type LoginAttemptStore interface {
+	Find(key string) (*domain.LoginAttempts, error)
+	Fail(key string, ttl time.Duration) (*domain.LoginAttempts, error)
+	Reset(key string) error
+}
+
+func loginAttemptKey(key string) string {
+	return "login:attempts:" + key
+}
+
+type redisLoginAttemptStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewRedisLoginAttemptStore(client *redis.Client, ctx context.Context) *redisLoginAttemptStore {
+	return &redisLoginAttemptStore{client: client, ctx: ctx}
+}
+
+func (r *redisLoginAttemptStore) Find(key string) (*domain.LoginAttempts, error) {
+	values, err := r.client.HGetAll(r.ctx, loginAttemptKey(key)).Result()
+
+	if err != nil {
+		return nil, fmt.Errorf("error finding login attempts: %w", err)
+	}
+
+	attempts := &domain.LoginAttempts{}
+
+	if len(values) == 0 {
+		return attempts, nil
+	}
+
+	if attempts.Failures, err = strconv.Atoi(values["failures"]); err != nil {
+		return nil, fmt.Errorf("error finding login attempts: %w", err)
+	}
+
+	last, err := strconv.ParseInt(values["last"], 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("error finding login attempts: %w", err)
+	}
+
+	attempts.LastFailedAt = time.UnixMilli(last)
+
+	return attempts, nil
+}
+
+func (r *redisLoginAttemptStore) Fail(key string, ttl time.Duration) (*domain.LoginAttempts, error) {
+	now := time.Now()
+	redisKey := loginAttemptKey(key)
+
+	var failures *redis.IntCmd
+
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		failures = pipe.HIncrBy(r.ctx, redisKey, "failures", 1)
+		pipe.HSet(r.ctx, redisKey, "last", now.UnixMilli())
+		pipe.Expire(r.ctx, redisKey, ttl)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error recording login attempt: %w", err)
+	}
+
+	return &domain.LoginAttempts{Failures: int(failures.Val()), LastFailedAt: time.UnixMilli(now.UnixMilli())}, nil
+}
+
+func (r *redisLoginAttemptStore) Reset(key string) error {
+	if err := r.client.Del(r.ctx, loginAttemptKey(key)).Err(); err != nil {
+		return fmt.Errorf("error resetting login attempts: %w", err)
+	}
+
+	return nil
+}
+
+type memoryLoginAttempt struct {
+	attempts  domain.LoginAttempts
+	expiresAt time.Time
+}
+
+type memoryLoginAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryLoginAttempt
+	now     func() time.Time
+}
+
+// NewMemoryLoginAttemptStore keeps the counts in process. It is meant for
+// tests and single instance setups without Redis.
+func NewMemoryLoginAttemptStore() *memoryLoginAttemptStore {
+	return &memoryLoginAttemptStore{entries: map[string]*memoryLoginAttempt{}, now: time.Now}
+}
+
+func (m *memoryLoginAttemptStore) Find(key string) (*domain.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+
+	if !ok || !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return &domain.LoginAttempts{}, nil
+	}
+
+	attempts := entry.attempts
+
+	return &attempts, nil
+}
+
+func (m *memoryLoginAttemptStore) Fail(key string, ttl time.Duration) (*domain.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	entry, ok := m.entries[key]
+
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = &memoryLoginAttempt{}
+		m.entries[key] = entry
+	}
+
+	entry.attempts.Failures++
+	entry.attempts.LastFailedAt = now
+	entry.expiresAt = now.Add(ttl)
+
+	attempts := entry.attempts
+
+	return &attempts, nil
+}
+
+func (m *memoryLoginAttemptStore) Reset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLoginAttemptStore_Fail(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	now := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	attempts, err := store.Find("user:alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, attempts.Failures)
+
+	store.Fail("user:alice", time.Minute)
+	now = now.Add(time.Second)
+	attempts, err = store.Fail("user:alice", time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts.Failures)
+	assert.Equal(t, now, attempts.LastFailedAt)
+
+	attempts, _ = store.Find("user:alice")
+	assert.Equal(t, 2, attempts.Failures)
+
+	attempts, _ = store.Find("ip:127.0.0.1")
+	assert.Equal(t, 0, attempts.Failures)
+}
+
+func TestMemoryLoginAttemptStore_Expiry(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	now := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	store.Fail("user:alice", time.Minute)
+
+	now = now.Add(time.Minute)
+
+	attempts, _ := store.Find("user:alice")
+	assert.Equal(t, 0, attempts.Failures)
+
+	attempts, _ = store.Fail("user:alice", time.Minute)
+	assert.Equal(t, 1, attempts.Failures)
+}
+
+func TestMemoryLoginAttemptStore_Reset(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+
+	store.Fail("user:alice", time.Minute)
+	assert.NoError(t, store.Reset("user:alice"))
+
+	attempts, _ := store.Find("user:alice")
+	assert.Equal(t, 0, attempts.Failures)
+}
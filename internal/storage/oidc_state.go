@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrStateNotFound = errors.New("sign-in state not found or expired")
+
+// OIDCStateStore keeps external sign-ins in progress, keyed by the state
+// parameter. Each state can be taken once.
+type OIDCStateStore interface {
+	Save(key string, state *domain.OIDCState, ttl time.Duration) error
+	Take(key string) (*domain.OIDCState, error)
+}
+
+func oidcStateKey(key string) string {
+	return "oidc:state:" + key
+}
+
+type redisOIDCStateStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewRedisOIDCStateStore(client *redis.Client, ctx context.Context) *redisOIDCStateStore {
+	return &redisOIDCStateStore{client: client, ctx: ctx}
+}
+
+func (r *redisOIDCStateStore) Save(key string, state *domain.OIDCState, ttl time.Duration) error {
+	value, err := json.Marshal(state)
+
+	if err != nil {
+		return fmt.Errorf("error saving sign-in state: %w", err)
+	}
+
+	if err := r.client.Set(r.ctx, oidcStateKey(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("error saving sign-in state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisOIDCStateStore) Take(key string) (*domain.OIDCState, error) {
+	value, err := r.client.GetDel(r.ctx, oidcStateKey(key)).Bytes()
+
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrStateNotFound
+		}
+		return nil, fmt.Errorf("error taking sign-in state: %w", err)
+	}
+
+	state := new(domain.OIDCState)
+
+	if err := json.Unmarshal(value, state); err != nil {
+		return nil, fmt.Errorf("error taking sign-in state: %w", err)
+	}
+
+	return state, nil
+}
+
+type memoryOIDCState struct {
+	state     domain.OIDCState
+	expiresAt time.Time
+}
+
+type memoryOIDCStateStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryOIDCState
+	now     func() time.Time
+}
+
+// NewMemoryOIDCStateStore keeps the states in process. It is meant for tests
+// and single instance setups without Redis.
+func NewMemoryOIDCStateStore() *memoryOIDCStateStore {
+	return &memoryOIDCStateStore{entries: map[string]memoryOIDCState{}, now: time.Now}
+}
+
+func (m *memoryOIDCStateStore) Save(key string, state *domain.OIDCState, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = memoryOIDCState{state: *state, expiresAt: m.now().Add(ttl)}
+
+	return nil
+}
+
+func (m *memoryOIDCStateStore) Take(key string) (*domain.OIDCState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	delete(m.entries, key)
+
+	if !ok || !m.now().Before(entry.expiresAt) {
+		return nil, ErrStateNotFound
+	}
+
+	return &entry.state, nil
+}
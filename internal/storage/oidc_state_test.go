@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryOIDCStateStore_Take(t *testing.T) {
+	store := NewMemoryOIDCStateStore()
+	state := &domain.OIDCState{Provider: "google", Nonce: "n", Verifier: "v"}
+
+	assert.NoError(t, store.Save("abc", state, time.Minute))
+
+	taken, err := store.Take("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, state, taken)
+
+	_, err = store.Take("abc")
+	assert.ErrorIs(t, err, ErrStateNotFound)
+
+	_, err = store.Take("other")
+	assert.ErrorIs(t, err, ErrStateNotFound)
+}
+
+func TestMemoryOIDCStateStore_Expiry(t *testing.T) {
+	store := NewMemoryOIDCStateStore()
+	now := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.Save("abc", &domain.OIDCState{Provider: "github"}, time.Minute))
+
+	now = now.Add(time.Minute)
+
+	_, err := store.Take("abc")
+	assert.ErrorIs(t, err, ErrStateNotFound)
+}
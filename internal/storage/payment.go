@@ -12,7 +12,11 @@ import (
 
 type PaymentRepository interface {
 	Save(payment *domain.Payment) (*domain.Payment, error)
+	Update(payment *domain.Payment) (*domain.Payment, error)
 	FindForBooking(bookingId int) (*domain.Payment, error)
+	FindByProviderRef(provider string, ref string) (*domain.Payment, error)
+	SaveRefund(refund *domain.Refund) (*domain.Refund, error)
+	FindRefundsForBooking(bookingId int) ([]*domain.Refund, error)
 }
 
 type paymentRepository struct {
@@ -27,45 +31,132 @@ func NewPaymentRepository(db *sqlx.DB, ctx context.Context) *paymentRepository {
 func (r *paymentRepository) Save(payment *domain.Payment) (*domain.Payment, error) {
 
 	query := `
-		INSERT INTO payments (booking_id, name, is_successful, price, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO payments (booking_id, name, is_successful, price, provider, provider_ref, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at, updated_at
 	`
 
 	now := time.Now()
 
 	payment.CreatedAt = now
+	payment.UpdatedAt = now
 
 	err := r.db.QueryRowxContext(r.ctx, query,
 		payment.BookingID,
 		payment.Name,
 		payment.IsSuccessful,
 		payment.Price,
+		payment.Provider,
+		payment.ProviderRef,
+		payment.Status,
 		payment.CreatedAt,
-	).Scan(&payment.ID, &payment.CreatedAt)
+		payment.UpdatedAt,
+	).Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error saving payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+func (r *paymentRepository) Update(payment *domain.Payment) (*domain.Payment, error) {
+	query := `
+		UPDATE payments
+		SET is_successful = $1, provider_ref = $2, status = $3, updated_at = $4
+		WHERE id = $5
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		payment.IsSuccessful,
+		payment.ProviderRef,
+		payment.Status,
+		time.Now(),
+		payment.ID,
+	).Scan(&payment.UpdatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("error updating payment: %w", err)
 	}
 
 	return payment, nil
 }
 
 func (r *paymentRepository) FindForBooking(bookingId int) (*domain.Payment, error) {
-	var payment *domain.Payment
+	var payment domain.Payment
 
 	query := `
-		SELECT id, booking_id, name, is_successful, price, created_at
+		SELECT id, booking_id, name, is_successful, price, provider, provider_ref, status, created_at, updated_at
 		FROM payments
 		WHERE booking_id = $1
 	`
 
 	if err := r.db.GetContext(r.ctx, &payment, query, bookingId); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("payment for booking with booking_id id %d not found", bookingId)
+			return nil, fmt.Errorf("payment for booking with booking_id id %d not found: %w", bookingId, err)
 		}
 		return nil, fmt.Errorf("error finding payment for booking: %w", err)
 	}
 
-	return payment, nil
+	return &payment, nil
+}
+
+func (r *paymentRepository) FindByProviderRef(provider string, ref string) (*domain.Payment, error) {
+	var payment domain.Payment
+
+	query := `
+		SELECT id, booking_id, name, is_successful, price, provider, provider_ref, status, created_at, updated_at
+		FROM payments
+		WHERE provider = $1 AND provider_ref = $2
+	`
+
+	if err := r.db.GetContext(r.ctx, &payment, query, provider, ref); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment with reference %s not found: %w", ref, err)
+		}
+		return nil, fmt.Errorf("error finding payment: %w", err)
+	}
+
+	return &payment, nil
+}
+
+func (r *paymentRepository) SaveRefund(refund *domain.Refund) (*domain.Refund, error) {
+	query := `
+		INSERT INTO refunds (payment_id, booking_id, amount, provider_ref, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		refund.PaymentID,
+		refund.BookingID,
+		refund.Amount,
+		refund.ProviderRef,
+		refund.Status,
+		time.Now(),
+	).Scan(&refund.ID, &refund.CreatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("error saving refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+func (r *paymentRepository) FindRefundsForBooking(bookingId int) ([]*domain.Refund, error) {
+	var refunds []*domain.Refund
+
+	query := `
+		SELECT id, payment_id, booking_id, amount, provider_ref, status, created_at
+		FROM refunds
+		WHERE booking_id = $1
+		ORDER BY created_at ASC
+	`
+
+	if err := r.db.SelectContext(r.ctx, &refunds, query, bookingId); err != nil {
+		return nil, fmt.Errorf("error finding refunds for booking: %w", err)
+	}
+
+	return refunds, nil
 }
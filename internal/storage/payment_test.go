@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentStorage_Save(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewPaymentRepository(sqlxDB, context.Background())
+
+	ref := "fake_pi_000001"
+
+	payment := &domain.Payment{
+		BookingID:   1,
+		Name:        "Booking #1",
+		Price:       335,
+		Provider:    "fake",
+		ProviderRef: &ref,
+		Status:      "authorized",
+	}
+
+	query := `
+		INSERT INTO payments \(booking_id, name, is_successful, price, provider, provider_ref, status, created_at, updated_at\)
+		VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now()
+
+	mock.ExpectQuery(query).
+		WithArgs(1, "Booking #1", false, 335.0, "fake", &ref, "authorized", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(3, now, now))
+
+	saved, err := repo.Save(payment)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, saved.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentStorage_FindByProviderRefNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewPaymentRepository(sqlxDB, context.Background())
+
+	mock.ExpectQuery(`SELECT (.+) FROM payments WHERE provider = \$1 AND provider_ref = \$2`).
+		WithArgs("fake", "missing").
+		WillReturnError(sql.ErrNoRows)
+
+	payment, err := repo.FindByProviderRef("fake", "missing")
+
+	assert.Nil(t, payment)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
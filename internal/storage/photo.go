@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 type PhotoRepository interface {
 	Insert(req *domain.Photo) (*domain.Photo, error)
 	FindAllForListing(listingID int) ([]*domain.Photo, error)
+	FindOne(id int) (*domain.Photo, error)
 	Remove(id string) error
 }
 
@@ -42,6 +44,25 @@ func (r *photoRepository) FindAllForListing(listingID int) ([]*domain.Photo, err
 	return photos, nil
 }
 
+func (r *photoRepository) FindOne(id int) (*domain.Photo, error) {
+	query := `
+			SELECT id, listing_id, public_id, url, created_at
+			FROM photos
+			WHERE id = $1
+		`
+
+	var photo domain.Photo
+
+	if err := r.db.GetContext(r.ctx, &photo, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("photo with id %d not found: %w", id, err)
+		}
+		return nil, fmt.Errorf("error finding photo: %w", err)
+	}
+
+	return &photo, nil
+}
+
 func (r *photoRepository) Insert(photo *domain.Photo) (*domain.Photo, error) {
 	query := `
 			INSERT INTO photos (listing_id, public_id, url, created_at)
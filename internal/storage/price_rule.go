@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+type PriceRuleRepository interface {
+	FindAllForListing(listingId int) ([]*domain.PriceRule, error)
+	Replace(listingId int, rules []*domain.PriceRule) ([]*domain.PriceRule, error)
+}
+
+type priceRuleRepository struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func NewPriceRuleRepository(db *sqlx.DB, ctx context.Context) *priceRuleRepository {
+	return &priceRuleRepository{db: db, ctx: ctx}
+}
+
+func (r *priceRuleRepository) FindAllForListing(listingId int) ([]*domain.PriceRule, error) {
+	query := `
+		SELECT id, listing_id, kind, percent, price, start_date, end_date, created_at
+		FROM listing_price_rules
+		WHERE listing_id = $1
+		ORDER BY kind ASC, start_date ASC
+	`
+
+	var rules []*domain.PriceRule
+
+	if err := r.db.SelectContext(r.ctx, &rules, query, listingId); err != nil {
+		return nil, fmt.Errorf("error fetching price rules for listing: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Replace swaps every price rule of the listing for rules at once.
+func (r *priceRuleRepository) Replace(listingId int, rules []*domain.PriceRule) ([]*domain.PriceRule, error) {
+	tx, err := r.db.BeginTxx(r.ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(r.ctx, "DELETE FROM listing_price_rules WHERE listing_id = $1", listingId); err != nil {
+		return nil, fmt.Errorf("error removing price rules: %w", err)
+	}
+
+	query := `
+		INSERT INTO listing_price_rules (listing_id, kind, percent, price, start_date, end_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	now := time.Now()
+
+	for _, rule := range rules {
+		rule.ListingID = listingId
+
+		err := tx.QueryRowxContext(r.ctx, query,
+			rule.ListingID,
+			rule.Kind,
+			rule.Percent,
+			rule.Price,
+			rule.StartDate,
+			rule.EndDate,
+			now,
+		).Scan(&rule.ID, &rule.CreatedAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("error saving price rule: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing price rules: %w", err)
+	}
+
+	return rules, nil
+}
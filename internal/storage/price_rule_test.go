@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceRuleStorage_Replace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewPriceRuleRepository(sqlxDB, context.Background())
+
+	weekend, price := 15.0, 180.0
+	startDate := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	rules := []*domain.PriceRule{
+		{Kind: domain.RuleWeekend, Percent: &weekend},
+		{Kind: domain.RuleSeason, Price: &price, StartDate: &startDate, EndDate: &endDate},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM listing_price_rules WHERE listing_id = \$1`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`INSERT INTO listing_price_rules`).
+		WithArgs(3, domain.RuleWeekend, &weekend, nil, nil, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(10, now))
+	mock.ExpectQuery(`INSERT INTO listing_price_rules`).
+		WithArgs(3, domain.RuleSeason, nil, &price, &startDate, &endDate, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(11, now))
+	mock.ExpectCommit()
+
+	saved, err := repo.Replace(3, rules)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, saved[0].ID)
+	assert.Equal(t, 11, saved[1].ID)
+	assert.Equal(t, 3, saved[1].ListingID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+// ErrReviewExists is returned when a booking has already been reviewed.
+var ErrReviewExists = errors.New("booking has already been reviewed")
+
+const uniqueViolation = "23505"
+
+type ReviewRepository interface {
+	Save(review *domain.Review) (*domain.Review, error)
+	Update(review *domain.Review) (*domain.Review, error)
+	FindOne(id int) (*domain.Review, error)
+	FindAllForListing(listingId int, page int, limit int) ([]*domain.Review, int, int, error)
+	FindRating(listingId int) (float64, int, error)
+	FindAllForAuthor(authorId int) ([]*domain.Review, error)
+}
+
+type reviewRepository struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func NewReviewRepository(db *sqlx.DB, ctx context.Context) *reviewRepository {
+	return &reviewRepository{db: db, ctx: ctx}
+}
+
+func (r *reviewRepository) Save(review *domain.Review) (*domain.Review, error) {
+	query := `
+		INSERT INTO reviews (listing_id, author_id, booking_id, rating, comment, is_published, is_edited, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now()
+	review.IsPublished = true
+	review.IsEdited = false
+	review.CreatedAt = now
+	review.UpdatedAt = now
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		review.ListingID,
+		review.AuthorID,
+		review.BookingID,
+		review.Rating,
+		review.Comment,
+		review.IsPublished,
+		review.IsEdited,
+		review.CreatedAt,
+		review.UpdatedAt,
+	).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil, ErrReviewExists
+		}
+		return nil, fmt.Errorf("error saving review: %w", err)
+	}
+
+	return review, nil
+}
+
+func (r *reviewRepository) Update(review *domain.Review) (*domain.Review, error) {
+	query := `
+		UPDATE reviews
+		SET rating = $1, comment = $2, is_published = $3, is_edited = $4, updated_at = $5
+		WHERE id = $6
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		review.Rating,
+		review.Comment,
+		review.IsPublished,
+		review.IsEdited,
+		time.Now(),
+		review.ID,
+	).Scan(&review.UpdatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("error updating review: %w", err)
+	}
+
+	return review, nil
+}
+
+func (r *reviewRepository) FindOne(id int) (*domain.Review, error) {
+	var review domain.Review
+
+	query := `
+		SELECT id, listing_id, author_id, booking_id, rating, comment, is_published, is_edited, created_at, updated_at
+		FROM reviews
+		WHERE id = $1
+	`
+
+	if err := r.db.GetContext(r.ctx, &review, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("review with id %d not found: %w", id, err)
+		}
+		return nil, fmt.Errorf("error finding review: %w", err)
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepository) FindAllForListing(listingId int, page int, limit int) ([]*domain.Review, int, int, error) {
+	var reviews []*domain.Review
+	var total int
+
+	query := `
+		SELECT id, listing_id, author_id, booking_id, rating, comment, is_published, is_edited, created_at, updated_at
+		FROM reviews
+		WHERE listing_id = $1 AND is_published = TRUE
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	err := r.db.SelectContext(r.ctx, &reviews, query, listingId, limit, (page-1)*limit)
+
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("error finding reviews for listing: %w", err)
+	}
+
+	totalQuery := "SELECT COUNT(*) FROM reviews WHERE listing_id = $1 AND is_published = TRUE"
+	err = r.db.GetContext(r.ctx, &total, totalQuery, listingId)
+
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("error counting reviews for listing: %w", err)
+	}
+
+	totalPage := (total + limit - 1) / limit
+
+	return reviews, total, totalPage, nil
+}
+
+// FindRating returns the average rating and number of published reviews of
+// the listing.
+func (r *reviewRepository) FindRating(listingId int) (float64, int, error) {
+	var result struct {
+		Rating float64 `db:"rating"`
+		Count  int     `db:"count"`
+	}
+
+	query := `
+		SELECT COALESCE(ROUND(AVG(rating), 2), 0) AS rating, COUNT(*) AS count
+		FROM reviews
+		WHERE listing_id = $1 AND is_published = TRUE
+	`
+
+	if err := r.db.GetContext(r.ctx, &result, query, listingId); err != nil {
+		return 0, 0, fmt.Errorf("error finding rating for listing: %w", err)
+	}
+
+	return result.Rating, result.Count, nil
+}
+
+// FindAllForAuthor returns every review the user wrote, published or not.
+func (r *reviewRepository) FindAllForAuthor(authorId int) ([]*domain.Review, error) {
+	var reviews []*domain.Review
+
+	query := `
+		SELECT id, listing_id, author_id, booking_id, rating, comment, is_published, is_edited, created_at, updated_at
+		FROM reviews
+		WHERE author_id = $1
+		ORDER BY created_at ASC
+	`
+
+	if err := r.db.SelectContext(r.ctx, &reviews, query, authorId); err != nil {
+		return nil, fmt.Errorf("error finding reviews for author: %w", err)
+	}
+
+	return reviews, nil
+}
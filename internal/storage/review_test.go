@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewStorage_Save(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewReviewRepository(sqlxDB, context.Background())
+
+	review := &domain.Review{
+		ListingID: 1,
+		AuthorID:  2,
+		BookingID: 3,
+		Rating:    5,
+		Comment:   "Great stay",
+	}
+
+	query := `
+		INSERT INTO reviews \(listing_id, author_id, booking_id, rating, comment, is_published, is_edited, created_at, updated_at\)
+		VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now()
+
+	mock.ExpectQuery(query).
+		WithArgs(1, 2, 3, 5, "Great stay", true, false, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, now, now))
+
+	savedReview, err := repo.Save(review)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, savedReview.ID)
+	assert.True(t, savedReview.IsPublished)
+
+	mock.ExpectQuery(`INSERT INTO reviews`).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "reviews_booking_id_unique"})
+
+	savedReview, err = repo.Save(&domain.Review{ListingID: 1, AuthorID: 2, BookingID: 3, Rating: 4})
+
+	assert.Nil(t, savedReview)
+	assert.ErrorIs(t, err, ErrReviewExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewStorage_FindOneNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewReviewRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, listing_id, author_id, booking_id, rating, comment, is_published, is_edited, created_at, updated_at FROM reviews WHERE id = \$1`
+
+	mock.ExpectQuery(query).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+	review, err := repo.FindOne(1)
+
+	assert.Nil(t, review)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewStorage_FindRating(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewReviewRepository(sqlxDB, context.Background())
+
+	query := `SELECT COALESCE\(ROUND\(AVG\(rating\), 2\), 0\) AS rating, COUNT\(\*\) AS count FROM reviews WHERE listing_id = \$1 AND is_published = TRUE`
+
+	mock.ExpectQuery(query).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"rating", "count"}).AddRow(4.5, 2))
+
+	rating, count, err := repo.FindRating(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4.5, rating)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenClaims identifies an access token for revocation checks.
+type TokenClaims struct {
+	Jti       string
+	SessionID int
+	UserID    int
+	IssuedAt  time.Time
+}
+
+// RevocationStore is the denylist of access tokens that are still within
+// their lifetime but must no longer be accepted. Entries only need to outlive
+// the access tokens they cover, so every entry is written with a TTL.
+type RevocationStore interface {
+	// RevokeToken revokes a single access token by its jti.
+	RevokeToken(jti string, ttl time.Duration) error
+	// RevokeSession revokes every access token issued for a session.
+	RevokeSession(sessionId int, ttl time.Duration) error
+	// RevokeUser revokes every access token issued to the user up to at.
+	// Issue times only have second precision, so tokens issued within the
+	// same second as at are revoked too.
+	RevokeUser(userId int, at time.Time, ttl time.Duration) error
+	IsRevoked(claims TokenClaims) (bool, error)
+}
+
+func revokedTokenKey(jti string) string {
+	return "revoked:jti:" + jti
+}
+
+func revokedSessionKey(sessionId int) string {
+	return fmt.Sprintf("revoked:session:%d", sessionId)
+}
+
+func revokedUserKey(userId int) string {
+	return fmt.Sprintf("revoked:user:%d", userId)
+}
+
+type redisRevocationStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewRedisRevocationStore(client *redis.Client, ctx context.Context) *redisRevocationStore {
+	return &redisRevocationStore{client: client, ctx: ctx}
+}
+
+func (r *redisRevocationStore) RevokeToken(jti string, ttl time.Duration) error {
+	if err := r.client.Set(r.ctx, revokedTokenKey(jti), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("error revoking token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRevocationStore) RevokeSession(sessionId int, ttl time.Duration) error {
+	if err := r.client.Set(r.ctx, revokedSessionKey(sessionId), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRevocationStore) RevokeUser(userId int, at time.Time, ttl time.Duration) error {
+	if err := r.client.Set(r.ctx, revokedUserKey(userId), at.Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("error revoking user tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRevocationStore) IsRevoked(claims TokenClaims) (bool, error) {
+	values, err := r.client.MGet(r.ctx,
+		revokedTokenKey(claims.Jti),
+		revokedSessionKey(claims.SessionID),
+		revokedUserKey(claims.UserID),
+	).Result()
+
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("error checking token revocation: %w", err)
+	}
+
+	if claims.Jti != "" && values[0] != nil {
+		return true, nil
+	}
+
+	if claims.SessionID != 0 && values[1] != nil {
+		return true, nil
+	}
+
+	if value, ok := values[2].(string); ok {
+		at, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return false, fmt.Errorf("error checking token revocation: %w", err)
+		}
+
+		return claims.IssuedAt.Unix() <= at, nil
+	}
+
+	return false, nil
+}
+
+type memoryEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+type memoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryRevocationStore keeps the denylist in process. It is meant for
+// tests and single instance setups without Redis.
+func NewMemoryRevocationStore() *memoryRevocationStore {
+	return &memoryRevocationStore{entries: map[string]memoryEntry{}, now: time.Now}
+}
+
+// set stores the entry and drops every entry that has expired, so the map
+// only holds revocations of tokens that are still alive.
+func (m *memoryRevocationStore) set(key string, value int64, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	for k, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, k)
+		}
+	}
+
+	m.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+}
+
+func (m *memoryRevocationStore) get(key string) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+
+	if !ok {
+		return 0, false
+	}
+
+	if !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return 0, false
+	}
+
+	return entry.value, true
+}
+
+func (m *memoryRevocationStore) RevokeToken(jti string, ttl time.Duration) error {
+	m.set(revokedTokenKey(jti), 1, ttl)
+	return nil
+}
+
+func (m *memoryRevocationStore) RevokeSession(sessionId int, ttl time.Duration) error {
+	m.set(revokedSessionKey(sessionId), 1, ttl)
+	return nil
+}
+
+func (m *memoryRevocationStore) RevokeUser(userId int, at time.Time, ttl time.Duration) error {
+	m.set(revokedUserKey(userId), at.Unix(), ttl)
+	return nil
+}
+
+func (m *memoryRevocationStore) IsRevoked(claims TokenClaims) (bool, error) {
+	if _, ok := m.get(revokedTokenKey(claims.Jti)); ok && claims.Jti != "" {
+		return true, nil
+	}
+
+	if _, ok := m.get(revokedSessionKey(claims.SessionID)); ok && claims.SessionID != 0 {
+		return true, nil
+	}
+
+	if at, ok := m.get(revokedUserKey(claims.UserID)); ok {
+		return claims.IssuedAt.Unix() <= at, nil
+	}
+
+	return false, nil
+}
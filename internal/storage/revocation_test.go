@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRevocationStore_RevokeToken(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	claims := TokenClaims{Jti: "a", SessionID: 1, UserID: 1, IssuedAt: time.Now()}
+
+	revoked, err := store.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, store.RevokeToken("a", time.Minute))
+
+	revoked, err = store.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(TokenClaims{Jti: "b", SessionID: 1, UserID: 1, IssuedAt: time.Now()})
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestMemoryRevocationStore_RevokeSession(t *testing.T) {
+	store := NewMemoryRevocationStore()
+
+	assert.NoError(t, store.RevokeSession(2, time.Minute))
+
+	revoked, _ := store.IsRevoked(TokenClaims{Jti: "a", SessionID: 2, UserID: 1, IssuedAt: time.Now()})
+	assert.True(t, revoked)
+
+	revoked, _ = store.IsRevoked(TokenClaims{Jti: "b", SessionID: 3, UserID: 1, IssuedAt: time.Now()})
+	assert.False(t, revoked)
+}
+
+func TestMemoryRevocationStore_RevokeUser(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	at := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, store.RevokeUser(1, at, time.Hour))
+
+	store.now = func() time.Time { return at }
+
+	revoked, _ := store.IsRevoked(TokenClaims{Jti: "old", UserID: 1, IssuedAt: at.Add(-time.Minute)})
+	assert.True(t, revoked)
+
+	revoked, _ = store.IsRevoked(TokenClaims{Jti: "same", UserID: 1, IssuedAt: at.Add(500 * time.Millisecond)})
+	assert.True(t, revoked)
+
+	revoked, _ = store.IsRevoked(TokenClaims{Jti: "new", UserID: 1, IssuedAt: at.Add(time.Second)})
+	assert.False(t, revoked)
+
+	revoked, _ = store.IsRevoked(TokenClaims{Jti: "other", UserID: 2, IssuedAt: at.Add(-time.Minute)})
+	assert.False(t, revoked)
+}
+
+func TestMemoryRevocationStore_Expiry(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	now := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.RevokeToken("a", time.Minute))
+
+	now = now.Add(time.Minute)
+
+	revoked, _ := store.IsRevoked(TokenClaims{Jti: "a", IssuedAt: now})
+	assert.False(t, revoked)
+}
+
+func TestMemoryRevocationStore_EvictsExpired(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	now := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.RevokeToken("a", time.Minute))
+	assert.NoError(t, store.RevokeSession(1, time.Minute))
+
+	now = now.Add(time.Minute)
+
+	assert.NoError(t, store.RevokeToken("b", time.Minute))
+
+	assert.Len(t, store.entries, 1)
+	assert.Contains(t, store.entries, revokedTokenKey("b"))
+}
@@ -14,6 +14,7 @@ type RoleStorage interface {
 	FindRoleByName(name string) (*domain.Role, error)
 	FindRoleById(id int) (*domain.Role, error)
 	RemoveRoleFromUser(userRole *domain.UserRole) error
+	FindAll() ([]domain.Role, error)
 }
 
 type RoleRepository struct {
@@ -91,3 +92,17 @@ func (r *RoleRepository) RemoveRoleFromUser(userRole *domain.UserRole) error {
 	}
 	return nil
 }
+
+func (r *RoleRepository) FindAll() ([]domain.Role, error) {
+	query := `
+		SELECT id, role_name, description, created_at, updated_at
+		FROM roles
+		ORDER BY id
+	`
+	var roles []domain.Role
+	err := r.db.SelectContext(r.ctx, &roles, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find roles: %w", err)
+	}
+	return roles, nil
+}
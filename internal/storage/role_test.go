@@ -148,3 +148,26 @@ func TestRoleStorage_RemoveRoleFromUser(t *testing.T) {
 	err = repo.RemoveRoleFromUser(userRole)
 	assert.NoError(t, err)
 }
+
+func TestRoleStorage_FindAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock DB: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewRoleRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, role_name, description, created_at, updated_at FROM roles ORDER BY id`
+	rows := sqlmock.NewRows([]string{"id", "role_name", "description", "created_at", "updated_at"}).
+		AddRow(1, "user", nil, time.Now(), time.Now()).
+		AddRow(2, "admin", nil, time.Now(), time.Now())
+
+	mock.ExpectQuery(query).WillReturnRows(rows)
+
+	roles, err := repo.FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, roles, 2)
+	assert.Equal(t, "admin", roles[1].RoleName)
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/may20xx/booking/internal/domain"
+)
+
+type SessionRepository interface {
+	Save(session *domain.Session) (*domain.Session, error)
+	FindOne(id int) (*domain.Session, error)
+	FindAllForUser(userId int) ([]*domain.Session, error)
+	Touch(id int, ip string) error
+	Revoke(id int) error
+	RevokeAllForUser(userId int, exceptId int) error
+}
+
+type sessionRepository struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func NewSessionRepository(db *sqlx.DB, ctx context.Context) *sessionRepository {
+	return &sessionRepository{db: db, ctx: ctx}
+}
+
+func (r *sessionRepository) Save(session *domain.Session) (*domain.Session, error) {
+	query := `
+		INSERT INTO sessions (user_id, user_agent, ip, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, last_used_at
+	`
+
+	now := time.Now()
+
+	err := r.db.QueryRowxContext(r.ctx, query,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		now,
+		now,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("error saving session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) FindOne(id int) (*domain.Session, error) {
+	var session domain.Session
+
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+	`
+
+	if err := r.db.GetContext(r.ctx, &session, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session with id %d not found: %w", id, err)
+		}
+		return nil, fmt.Errorf("error finding session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// FindAllForUser returns the user's active sessions, most recently used first.
+func (r *sessionRepository) FindAllForUser(userId int) ([]*domain.Session, error) {
+	var sessions []*domain.Session
+
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_used_at DESC
+	`
+
+	if err := r.db.SelectContext(r.ctx, &sessions, query, userId); err != nil {
+		return nil, fmt.Errorf("error finding sessions for user: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(id int, ip string) error {
+	query := `
+		UPDATE sessions
+		SET last_used_at = $1, ip = $2
+		WHERE id = $3
+	`
+
+	if _, err := r.db.ExecContext(r.ctx, query, time.Now(), ip, id); err != nil {
+		return fmt.Errorf("error updating session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Revoke(id int) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`
+
+	if _, err := r.db.ExecContext(r.ctx, query, time.Now(), id); err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every active session of the user but exceptId;
+// pass 0 to revoke them all.
+func (r *sessionRepository) RevokeAllForUser(userId int, exceptId int) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL
+	`
+
+	if _, err := r.db.ExecContext(r.ctx, query, time.Now(), userId, exceptId); err != nil {
+		return fmt.Errorf("error revoking sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/may20xx/booking/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionStorage_Save(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewSessionRepository(sqlxDB, context.Background())
+
+	query := `
+		INSERT INTO sessions \(user_id, user_agent, ip, created_at, last_used_at\)
+		VALUES \(\$1, \$2, \$3, \$4, \$5\)
+		RETURNING id, created_at, last_used_at
+	`
+
+	now := time.Now()
+
+	mock.ExpectQuery(query).
+		WithArgs(1, "curl/8.0", "127.0.0.1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).AddRow(3, now, now))
+
+	session, err := repo.Save(&domain.Session{UserID: 1, UserAgent: "curl/8.0", IP: "127.0.0.1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, session.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionStorage_FindOneNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewSessionRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, user_id, user_agent, ip, created_at, last_used_at, revoked_at FROM sessions WHERE id = \$1`
+
+	mock.ExpectQuery(query).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+	session, err := repo.FindOne(1)
+
+	assert.Nil(t, session)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionStorage_RevokeAllForUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewSessionRepository(sqlxDB, context.Background())
+
+	query := `UPDATE sessions SET revoked_at = \$1 WHERE user_id = \$2 AND id <> \$3 AND revoked_at IS NULL`
+
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 1, 3).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.RevokeAllForUser(1, 3)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
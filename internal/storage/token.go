@@ -16,6 +16,10 @@ type TokenStorage interface {
 	Remove(id int) error
 	FindOneByToken(token string, userId int) (*domain.Token, error)
 	FindOneByValue(value string) (*domain.Token, error)
+	Revoke(id int) (bool, error)
+	RevokeAllForUser(userId int, name string) error
+	RevokeAllForUserExcept(userId int, name string, sessionId int) error
+	CountSince(userId int, name string, since time.Time) (int, error)
 }
 
 type TokenRepository struct {
@@ -29,7 +33,7 @@ func NewTokenRepository(db *sqlx.DB, ctx context.Context) *TokenRepository {
 
 func (r *TokenRepository) FindOneByValue(value string) (*domain.Token, error) {
 	query := `
-        SELECT id, user_id, token, name, created_at, updated_at, expired_at
+        SELECT id, user_id, token, name, created_at, updated_at, expired_at, revoked_at, session_id
         FROM tokens
         WHERE token = $1
     `
@@ -43,11 +47,13 @@ func (r *TokenRepository) FindOneByValue(value string) (*domain.Token, error) {
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.ExpiredAt,
+		&t.RevokedAt,
+		&t.SessionID,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, fmt.Errorf("token not found: %w", err)
 		}
 		return nil, fmt.Errorf("error querying token: %w", err)
 	}
@@ -84,8 +90,8 @@ func (r *TokenRepository) FindOneByToken(tokenName string, userId int) (*domain.
 }
 func (r *TokenRepository) Insert(token *domain.Token) (*domain.Token, error) {
 	query := `
-		INSERT INTO tokens (user_id, token, name, created_at, updated_at, expired_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO tokens (user_id, token, name, created_at, updated_at, expired_at, session_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -100,6 +106,7 @@ func (r *TokenRepository) Insert(token *domain.Token) (*domain.Token, error) {
 		token.CreatedAt,
 		token.UpdatedAt,
 		token.ExpiredAt,
+		token.SessionID,
 	).Scan(&token.ID, &token.CreatedAt, &token.UpdatedAt)
 
 	if err != nil {
@@ -148,3 +155,79 @@ func (r *TokenRepository) Remove(id int) error {
 
 	return nil
 }
+
+// Revoke marks the token as revoked. It reports false when the token was
+// already revoked, which lets callers detect a concurrent rotation.
+func (r *TokenRepository) Revoke(id int) (bool, error) {
+	query := `
+		UPDATE tokens
+		SET revoked_at = $1, updated_at = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(r.ctx, query, time.Now(), id)
+
+	if err != nil {
+		return false, fmt.Errorf("error revoking token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, fmt.Errorf("error revoking token: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *TokenRepository) RevokeAllForUser(userId int, name string) error {
+	query := `
+		UPDATE tokens
+		SET revoked_at = $1, updated_at = $1
+		WHERE user_id = $2 AND name = $3 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(r.ctx, query, time.Now(), userId, name)
+
+	if err != nil {
+		return fmt.Errorf("error revoking tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUserExcept revokes the user's tokens of the given type except
+// the ones belonging to sessionId.
+func (r *TokenRepository) RevokeAllForUserExcept(userId int, name string, sessionId int) error {
+	query := `
+		UPDATE tokens
+		SET revoked_at = $1, updated_at = $1
+		WHERE user_id = $2 AND name = $3 AND revoked_at IS NULL AND session_id IS DISTINCT FROM $4
+	`
+
+	_, err := r.db.ExecContext(r.ctx, query, time.Now(), userId, name, sessionId)
+
+	if err != nil {
+		return fmt.Errorf("error revoking tokens: %w", err)
+	}
+
+	return nil
+}
+
+// CountSince counts the tokens of the given type issued to the user since the
+// given time, revoked or not.
+func (r *TokenRepository) CountSince(userId int, name string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM tokens
+		WHERE user_id = $1 AND name = $2 AND created_at >= $3
+	`
+
+	var count int
+
+	if err := r.db.GetContext(r.ctx, &count, query, userId, name, since); err != nil {
+		return 0, fmt.Errorf("error counting tokens: %w", err)
+	}
+
+	return count, nil
+}
@@ -32,12 +32,12 @@ func TestTokenStorage_Insert(t *testing.T) {
 	}
 
 	query := `
-		INSERT INTO tokens \(user_id, token, name, created_at, updated_at, expired_at\)
-		VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)
+		INSERT INTO tokens \(user_id, token, name, created_at, updated_at, expired_at, session_id\)
+		VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\)
 		RETURNING id, created_at, updated_at
 	`
 	mock.ExpectQuery(query).
-		WithArgs(token.UserID, token.Token, token.Name, sqlmock.AnyArg(), sqlmock.AnyArg(), token.ExpiredAt).
+		WithArgs(token.UserID, token.Token, token.Name, sqlmock.AnyArg(), sqlmock.AnyArg(), token.ExpiredAt, token.SessionID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
 			AddRow(1, time.Now(), time.Now()))
 
@@ -178,3 +178,69 @@ func TestTokenStorage_FindOneByToken(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestTokenStorage_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewTokenRepository(sqlxDB, context.Background())
+
+	query := `UPDATE tokens SET revoked_at = \$1, updated_at = \$1 WHERE id = \$2 AND revoked_at IS NULL`
+
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	revoked, err := repo.Revoke(1)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	revoked, err = repo.Revoke(1)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTokenStorage_RevokeAllForUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewTokenRepository(sqlxDB, context.Background())
+
+	query := `UPDATE tokens SET revoked_at = \$1, updated_at = \$1 WHERE user_id = \$2 AND name = \$3 AND revoked_at IS NULL`
+
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 1, "refresh_token").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	err = repo.RevokeAllForUser(1, "refresh_token")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTokenStorage_FindOneByValueNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewTokenRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, user_id, token, name, created_at, updated_at, expired_at, revoked_at, session_id FROM tokens WHERE token = \$1`
+
+	mock.ExpectQuery(query).WithArgs("missing").WillReturnError(sql.ErrNoRows)
+
+	token, err := repo.FindOneByValue("missing")
+	assert.Nil(t, token)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
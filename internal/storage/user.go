@@ -7,18 +7,26 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/may20xx/booking/internal/domain"
 )
 
 type UserRepository interface {
 	Insert(user *domain.User) (*domain.User, error)
 	Remove(id int) error
+	Anonymize(id int) error
 	FindOneById(id int) (*domain.User, error)
 	FindOneByEmail(email string) (*domain.User, error)
 	FindOneByUsername(username string) (*domain.User, error)
 	Update(user *domain.User) (*domain.User, error)
 	VerifyEmail(user *domain.User) (*domain.User, error)
 	FindLandlord(id int) (*domain.Landlord, error)
+	FindAuthors(ids []int) ([]*domain.Author, error)
+	UpdatePendingEmail(id int, email *string) error
+	ConfirmPendingEmail(id int) (string, error)
+	FindTwoFactor(id int) (*domain.TwoFactor, error)
+	UpdateTwoFactor(id int, secret *string, enabled bool) error
+	UseTwoFactorCounter(id int, counter int64) (bool, error)
 }
 
 type userRepository struct {
@@ -126,6 +134,70 @@ func (r *userRepository) Remove(id int) error {
 	return nil
 }
 
+// Anonymize erases the personal data of an account while keeping the row, so
+// the bookings, payments and reviews that other people rely on survive it.
+// Listings without bookings are deleted and the rest are archived. It fails
+// with sql.ErrNoRows when the account does not exist or is already deleted.
+func (r *userRepository) Anonymize(id int) error {
+	now := time.Now()
+
+	tx, err := r.db.BeginTxx(r.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET username = $1, email = $2, hash_password = '', first_name = 'Deleted', surname = 'User', avatar = NULL,
+			email_verify = FALSE, pending_email = NULL, totp_secret = NULL, totp_enabled = FALSE, totp_last_counter = 0,
+			deleted_at = $3, updated_at = $3
+		WHERE id = $4 AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(r.ctx, query,
+		fmt.Sprintf("deleted-%d", id),
+		fmt.Sprintf("deleted-%d@deleted.invalid", id),
+		now,
+		id,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error anonymizing user: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error anonymizing user: %w", err)
+	} else if affected == 0 {
+		return fmt.Errorf("user with id %d not found: %w", id, sql.ErrNoRows)
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE bookings SET phone_number = NULL, message_to_host = NULL WHERE guest_id = $1", []interface{}{id}},
+		{"DELETE FROM listings WHERE landlord_id = $1 AND NOT EXISTS (SELECT 1 FROM bookings WHERE bookings.listing_id = listings.id)", []interface{}{id}},
+		{"UPDATE listings SET archived_at = $1 WHERE landlord_id = $2 AND archived_at IS NULL", []interface{}{now, id}},
+		{"DELETE FROM user_identities WHERE user_id = $1", []interface{}{id}},
+		{"DELETE FROM user_roles WHERE user_id = $1", []interface{}{id}},
+		{"DELETE FROM tokens WHERE user_id = $1", []interface{}{id}},
+		{"DELETE FROM sessions WHERE user_id = $1", []interface{}{id}},
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(r.ctx, statement.query, statement.args...); err != nil {
+			return fmt.Errorf("error anonymizing user: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing anonymized user: %w", err)
+	}
+
+	return nil
+}
+
 func (r *userRepository) FindOneByEmail(email string) (*domain.User, error) {
 	query := `
 		SELECT id, username, email_verify, email, hash_password, first_name, surname, avatar, created_at, updated_at
@@ -138,7 +210,7 @@ func (r *userRepository) FindOneByEmail(email string) (*domain.User, error) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, fmt.Errorf("user with email %s not found: %w", email, err)
 		}
 		return nil, fmt.Errorf("error querying user: %w", err)
 	}
@@ -158,7 +230,7 @@ func (r *userRepository) FindOneByUsername(username string) (*domain.User, error
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with username %s not found", username)
+			return nil, fmt.Errorf("user with username %s not found: %w", username, err)
 		}
 		return nil, fmt.Errorf("error querying user: %w", err)
 	}
@@ -200,3 +272,117 @@ func (r *userRepository) FindLandlord(landlordId int) (*domain.Landlord, error)
 
 	return landlord, nil
 }
+
+// FindAuthors returns the public profiles of the users with the given ids.
+func (r *userRepository) FindAuthors(ids []int) ([]*domain.Author, error) {
+	query := `
+		SELECT id, username, first_name, surname, avatar FROM users WHERE id = ANY($1)
+	`
+
+	var authors []*domain.Author
+
+	if err := r.db.SelectContext(r.ctx, &authors, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("error querying authors: %w", err)
+	}
+
+	return authors, nil
+}
+
+func (r *userRepository) UpdatePendingEmail(id int, email *string) error {
+	query := `
+		UPDATE users
+		SET pending_email = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	_, err := r.db.ExecContext(r.ctx, query, email, time.Now(), id)
+
+	if err != nil {
+		return fmt.Errorf("error updating pending email: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmPendingEmail makes the pending email the user's address and marks it
+// verified. It fails with sql.ErrNoRows when there is no pending email or the
+// address has been taken by another account in the meantime.
+func (r *userRepository) ConfirmPendingEmail(id int) (string, error) {
+	query := `
+		UPDATE users
+		SET email = pending_email, pending_email = NULL, email_verify = TRUE, updated_at = $1
+		WHERE id = $2 AND pending_email IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM users u WHERE u.email = users.pending_email AND u.id <> users.id)
+		RETURNING email
+	`
+
+	var email string
+
+	if err := r.db.QueryRowxContext(r.ctx, query, time.Now(), id).Scan(&email); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("no pending email to confirm for user with id %d: %w", id, err)
+		}
+		return "", fmt.Errorf("error confirming pending email: %w", err)
+	}
+
+	return email, nil
+}
+
+func (r *userRepository) FindTwoFactor(id int) (*domain.TwoFactor, error) {
+	query := `
+		SELECT id, totp_secret, totp_enabled, totp_last_counter
+		FROM users
+		WHERE id = $1
+	`
+
+	var twoFactor domain.TwoFactor
+
+	if err := r.db.GetContext(r.ctx, &twoFactor, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user with id %d not found: %w", id, err)
+		}
+		return nil, fmt.Errorf("error querying two-factor settings: %w", err)
+	}
+
+	return &twoFactor, nil
+}
+
+// UpdateTwoFactor stores a new secret, or clears it when secret is nil, and
+// forgets the last accepted code along with the old secret.
+func (r *userRepository) UpdateTwoFactor(id int, secret *string, enabled bool) error {
+	query := `
+		UPDATE users
+		SET totp_secret = $1, totp_enabled = $2, totp_last_counter = 0, updated_at = $3
+		WHERE id = $4
+	`
+
+	if _, err := r.db.ExecContext(r.ctx, query, secret, enabled, time.Now(), id); err != nil {
+		return fmt.Errorf("error updating two-factor settings: %w", err)
+	}
+
+	return nil
+}
+
+// UseTwoFactorCounter records the period of an accepted code. It reports
+// false when a code from that period or a later one was already used.
+func (r *userRepository) UseTwoFactorCounter(id int, counter int64) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_last_counter = $1
+		WHERE id = $2 AND totp_last_counter < $1
+	`
+
+	result, err := r.db.ExecContext(r.ctx, query, counter, id)
+
+	if err != nil {
+		return false, fmt.Errorf("error using two-factor code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, fmt.Errorf("error using two-factor code: %w", err)
+	}
+
+	return affected == 1, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-faker/faker/v4"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/may20xx/booking/internal/domain"
 	"github.com/stretchr/testify/assert"
 )
@@ -90,16 +91,17 @@ func TestFindOneByEmail(t *testing.T) {
 	}
 
 	query := `
-		SELECT id, username, email, hash_password, first_name, surname, avatar, created_at, updated_at
+		SELECT id, username, email_verify, email, hash_password, first_name, surname, avatar, created_at, updated_at
 		FROM users
 		WHERE email = \$1
 	`
 
 	rows := sqlmock.NewRows([]string{
-		"id", "username", "email", "hash_password", "first_name", "surname", "avatar", "created_at", "updated_at",
+		"id", "username", "email_verify", "email", "hash_password", "first_name", "surname", "avatar", "created_at", "updated_at",
 	}).AddRow(
 		user.ID,
 		user.Username,
+		user.EmailVerify,
 		user.Email,
 		user.HashPassword,
 		user.FirstName,
@@ -156,7 +158,7 @@ func TestFindOneByIdNotFound(t *testing.T) {
 
 	userId := 1
 	query := `
-        SELECT id, username, email, hash_password, first_name, surname, avatar, created_at, updated_at
+        SELECT id, username, email, hash_password, first_name, surname, avatar, created_at, updated_at , email_verify
         FROM users
         WHERE id = \$1
     `
@@ -170,3 +172,193 @@ func TestFindOneByIdNotFound(t *testing.T) {
 	assert.EqualError(t, err, "user with id 1 not found")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestConfirmPendingEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	ctx := context.Background()
+	repo := NewUserRepository(sqlxDB, ctx)
+
+	query := `
+		UPDATE users
+		SET email = pending_email, pending_email = NULL, email_verify = TRUE, updated_at = \$1
+		WHERE id = \$2 AND pending_email IS NOT NULL
+	`
+
+	mock.ExpectQuery(query).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("new@example.com"))
+
+	email, err := repo.ConfirmPendingEmail(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "new@example.com", email)
+
+	mock.ExpectQuery(query).WithArgs(sqlmock.AnyArg(), 1).WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.ConfirmPendingEmail(1)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserStorage_FindOneByUsernameNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewUserRepository(sqlxDB, context.Background())
+
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE username = \$1`).
+		WithArgs("alice").
+		WillReturnError(sql.ErrNoRows)
+
+	user, err := repo.FindOneByUsername("alice")
+
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindTwoFactor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewUserRepository(sqlxDB, context.Background())
+
+	query := `SELECT id, totp_secret, totp_enabled, totp_last_counter FROM users WHERE id = \$1`
+
+	mock.ExpectQuery(query).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "totp_secret", "totp_enabled", "totp_last_counter"}).
+			AddRow(1, "JBSWY3DPEHPK3PXP", true, 42))
+
+	twoFactor, err := repo.FindTwoFactor(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", *twoFactor.Secret)
+	assert.True(t, twoFactor.Enabled)
+	assert.Equal(t, int64(42), twoFactor.LastCounter)
+
+	mock.ExpectQuery(query).WithArgs(2).WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.FindTwoFactor(2)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseTwoFactorCounter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewUserRepository(sqlxDB, context.Background())
+
+	query := `UPDATE users SET totp_last_counter = \$1 WHERE id = \$2 AND totp_last_counter < \$1`
+
+	mock.ExpectExec(query).WithArgs(int64(100), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WithArgs(int64(100), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	used, err := repo.UseTwoFactorCounter(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	used, err = repo.UseTwoFactorCounter(1, 100)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewUserRepository(sqlxDB, context.Background())
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET username = \$1, email = \$2, hash_password = ''`).
+		WithArgs("deleted-1", "deleted-1@deleted.invalid", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE bookings SET phone_number = NULL, message_to_host = NULL WHERE guest_id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM listings WHERE landlord_id = \$1 AND NOT EXISTS`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE listings SET archived_at = \$1 WHERE landlord_id = \$2 AND archived_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM user_identities WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM user_roles WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM tokens WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM sessions WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Anonymize(1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnonymizeUserAlreadyDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewUserRepository(sqlxDB, context.Background())
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET username`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Anonymize(1)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindAuthors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewUserRepository(sqlxDB, context.Background())
+
+	mock.ExpectQuery(`SELECT id, username, first_name, surname, avatar FROM users WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]int{2, 5})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "first_name", "surname", "avatar"}).
+			AddRow(2, "guest", "Ana", "Lee", nil).
+			AddRow(5, "traveller", "Kai", "Sato", nil))
+
+	authors, err := repo.FindAuthors([]int{2, 5})
+
+	assert.NoError(t, err)
+	assert.Len(t, authors, 2)
+	assert.Equal(t, "traveller", authors[1].Username)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
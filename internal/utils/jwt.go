@@ -5,10 +5,18 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/may20xx/booking/config"
 	"github.com/may20xx/booking/internal/domain"
 )
 
+const (
+	// AccessTokenTTL is kept short so the revocation list stays small;
+	// clients renew access tokens through the refresh endpoint.
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type TokenResponse struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
@@ -20,6 +28,8 @@ type JwtPayload struct {
 	Exp      int64    `json:"exp"`
 	Username string   `json:"username"`
 	Roles    []string `json:"roles"`
+	Jti      string   `json:"jti,omitempty"`
+	Sid      int      `json:"sid,omitempty"`
 }
 
 func ConvertRolesToStrings(roles []domain.Role) []string {
@@ -30,7 +40,11 @@ func ConvertRolesToStrings(roles []domain.Role) []string {
 	return roleNames
 }
 
-func GenerateJWT(user *domain.User) (*TokenResponse, error) {
+// GenerateJWT signs an access and a refresh token for the user's session.
+// Access tokens are signed with the keyring so other services can verify them
+// through the JWKS endpoint. Refresh tokens are only ever read by this
+// service and stay on the shared JWTRefreshSecret.
+func GenerateJWT(user *domain.User, sessionId int) (*TokenResponse, error) {
 	config := config.GetConfig()
 	now := time.Now()
 
@@ -39,20 +53,27 @@ func GenerateJWT(user *domain.User) (*TokenResponse, error) {
 	accessPayload := JwtPayload{
 		Sub:      user.ID,
 		Iat:      now.Unix(),
-		Exp:      now.Add(30 * 24 * time.Hour).Unix(),
+		Exp:      now.Add(AccessTokenTTL).Unix(),
 		Username: user.Username,
 		Roles:    roleNames,
+		Jti:      uuid.NewString(),
+		Sid:      sessionId,
+	}
+
+	keys, err := GetKeyring()
+	if err != nil {
+		return nil, err
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	accessTokenString, err := keys.Sign(jwt.MapClaims{
 		"sub":      accessPayload.Sub,
 		"iat":      accessPayload.Iat,
 		"exp":      accessPayload.Exp,
 		"username": accessPayload.Username,
 		"roles":    accessPayload.Roles,
+		"jti":      accessPayload.Jti,
+		"sid":      accessPayload.Sid,
 	})
-
-	accessTokenString, err := accessToken.SignedString([]byte(config.JWTSecret))
 	if err != nil {
 		return nil, err
 	}
@@ -60,13 +81,17 @@ func GenerateJWT(user *domain.User) (*TokenResponse, error) {
 	refreshPayload := JwtPayload{
 		Sub: user.ID,
 		Iat: now.Unix(),
-		Exp: now.Add(time.Hour * 24 * 7).Unix(),
+		Exp: now.Add(RefreshTokenTTL).Unix(),
+		Jti: uuid.NewString(),
+		Sid: sessionId,
 	}
 
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": refreshPayload.Sub,
 		"iat": refreshPayload.Iat,
 		"exp": refreshPayload.Exp,
+		"jti": refreshPayload.Jti,
+		"sid": refreshPayload.Sid,
 	})
 
 	refreshTokenString, err := refreshToken.SignedString([]byte(config.JWTRefreshSecret))
@@ -80,16 +105,14 @@ func GenerateJWT(user *domain.User) (*TokenResponse, error) {
 	}, nil
 }
 
+// ValidateJWT checks an access token against the key named by its kid.
 func ValidateJWT(tokenString string) (*JwtPayload, error) {
-	config := config.GetConfig()
+	keys, err := GetKeyring()
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.JWTSecret), nil
-	})
+	token, err := jwt.Parse(tokenString, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
 
 	if err != nil {
 		return nil, err
@@ -128,12 +151,18 @@ func ValidateJWT(tokenString string) (*JwtPayload, error) {
 			}
 		}
 
+		// Tokens issued before sessions existed carry no sid or jti.
+		sid, _ := claims["sid"].(float64)
+		jti, _ := claims["jti"].(string)
+
 		payload := &JwtPayload{
 			Sub:      int(sub),
 			Iat:      int64(iat),
 			Exp:      int64(exp),
 			Username: username,
 			Roles:    roles,
+			Jti:      jti,
+			Sid:      int(sid),
 		}
 
 		return payload, nil
@@ -141,3 +170,55 @@ func ValidateJWT(tokenString string) (*JwtPayload, error) {
 
 	return nil, jwt.ErrTokenInvalidClaims
 }
+
+// ValidateRefreshJWT checks a refresh token signed with JWTRefreshSecret and
+// returns its subject, timestamps and id.
+func ValidateRefreshJWT(tokenString string) (*JwtPayload, error) {
+	config := config.GetConfig()
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(config.JWTRefreshSecret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid sub claim")
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid iat claim")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid exp claim")
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid jti claim")
+	}
+
+	sid, _ := claims["sid"].(float64)
+
+	return &JwtPayload{
+		Sub: int(sub),
+		Iat: int64(iat),
+		Exp: int64(exp),
+		Jti: jti,
+		Sid: int(sid),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/may20xx/booking/config"
+	"github.com/may20xx/booking/pkg/keyring"
+	"github.com/may20xx/booking/pkg/log"
+)
+
+var (
+	keys     *keyring.Keyring
+	keysOnce sync.Once
+	keysErr  error
+)
+
+// InitKeyring loads the access token keys from JWT_KEYS_DIR. The directory
+// is required unless APP_ENV is development, where an ephemeral key is
+// generated instead. That key signs everyone out on restart and differs
+// between instances.
+func InitKeyring() error {
+	keysOnce.Do(func() {
+		setting := config.GetConfig()
+
+		if setting.JWTKeysDir == "" {
+			if !setting.IsDevelopment() {
+				keysErr = fmt.Errorf("JWT_KEYS_DIR must be set unless APP_ENV is %s", config.Development)
+				return
+			}
+
+			log.Msg.Warn("JWT_KEYS_DIR is empty, signing access tokens with an ephemeral key")
+
+			key, err := keyring.Generate("ephemeral")
+			if err != nil {
+				keysErr = err
+				return
+			}
+
+			keys, keysErr = keyring.New(key.ID, key)
+			return
+		}
+
+		loaded, err := keyring.LoadDir(setting.JWTKeysDir)
+		if err != nil {
+			keysErr = err
+			return
+		}
+
+		if len(loaded) == 0 {
+			keysErr = fmt.Errorf("no keys found in %s", setting.JWTKeysDir)
+			return
+		}
+
+		signingId := setting.JWTSigningKeyID
+		if signingId == "" && len(loaded) == 1 {
+			signingId = loaded[0].ID
+		}
+
+		keys, keysErr = keyring.New(signingId, loaded...)
+	})
+	return keysErr
+}
+
+// GetKeyring returns the access token keys, loading them on first use.
+func GetKeyring() (*keyring.Keyring, error) {
+	if err := InitKeyring(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -37,3 +37,28 @@ func NewPaginationResponse(total int, totalPages int, page int, limit int, resul
 		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 }
+
+// CursorPagination is a page of results that continues from NextCursor. It
+// is null on the last page.
+type CursorPagination struct {
+	Code       int         `json:"httpCode"`
+	Limit      int         `json:"limit"`
+	NextCursor *string     `json:"next_cursor"`
+	Result     interface{} `json:"result"`
+	Timestamp  string      `json:"timestamp"`
+}
+
+func NewCursorPaginationResponse(nextCursor string, limit int, result interface{}) *CursorPagination {
+	res := &CursorPagination{
+		Code:      200,
+		Limit:     limit,
+		Result:    result,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if nextCursor != "" {
+		res.NextCursor = &nextCursor
+	}
+
+	return res
+}
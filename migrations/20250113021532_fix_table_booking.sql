@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE bookings RENAME COLUMN message_the_host TO message_to_host;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE bookings RENAME COLUMN message_to_host TO message_the_host;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE bookings ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'confirmed', 'declined', 'cancelled', 'completed'));
+
+-- Cancelled and declined bookings give their nights back.
+ALTER TABLE bookings DROP CONSTRAINT bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (listing_id WITH =, daterange(start_date, end_date, '[)') WITH &&)
+    WHERE (status IN ('pending', 'confirmed'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE bookings DROP CONSTRAINT bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (listing_id WITH =, daterange(start_date, end_date, '[)') WITH &&);
+ALTER TABLE bookings DROP COLUMN status;
+-- +goose StatementEnd
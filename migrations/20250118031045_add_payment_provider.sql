@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE payments ADD COLUMN provider VARCHAR(50) NOT NULL DEFAULT 'fake';
+ALTER TABLE payments ADD COLUMN provider_ref VARCHAR(255) UNIQUE;
+ALTER TABLE payments ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE payments ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE payments DROP COLUMN updated_at;
+ALTER TABLE payments DROP COLUMN status;
+ALTER TABLE payments DROP COLUMN provider_ref;
+ALTER TABLE payments DROP COLUMN provider;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE cancellation_policies (
+    listing_id INT PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+
+    name VARCHAR(20) NOT NULL CHECK (name IN ('flexible', 'moderate', 'strict')),
+    cutoff_days INT NOT NULL CHECK (cutoff_days >= 0),
+    refund_percent INT NOT NULL CHECK (refund_percent BETWEEN 0 AND 100),
+    late_refund_percent INT NOT NULL CHECK (late_refund_percent BETWEEN 0 AND 100),
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    payment_id INT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    booking_id INT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount >= 0),
+    provider_ref VARCHAR(255) UNIQUE,
+    status VARCHAR(20) NOT NULL,
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE refunds;
+DROP TABLE cancellation_policies;
+-- +goose StatementEnd
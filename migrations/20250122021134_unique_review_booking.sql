@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE reviews ADD CONSTRAINT reviews_booking_id_unique UNIQUE (booking_id);
+CREATE INDEX reviews_listing_id_idx ON reviews (listing_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX reviews_listing_id_idx;
+ALTER TABLE reviews DROP CONSTRAINT reviews_booking_id_unique;
+-- +goose StatementEnd
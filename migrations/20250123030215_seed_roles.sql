@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+INSERT INTO roles (role_name, description)
+VALUES ('user', 'Guest and host of listings'),
+       ('manager', 'Manages the listing catalogs'),
+       ('admin', 'Manages users, roles and content')
+ON CONFLICT (role_name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
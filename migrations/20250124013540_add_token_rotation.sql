@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE tokens ALTER COLUMN token TYPE TEXT;
+ALTER TABLE tokens ADD COLUMN revoked_at TIMESTAMP;
+CREATE INDEX tokens_token_idx ON tokens (token);
+CREATE INDEX tokens_user_id_name_idx ON tokens (user_id, name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX tokens_user_id_name_idx;
+DROP INDEX tokens_token_idx;
+ALTER TABLE tokens DROP COLUMN revoked_at;
+ALTER TABLE tokens ALTER COLUMN token TYPE VARCHAR(255);
+-- +goose StatementEnd
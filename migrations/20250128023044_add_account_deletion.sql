@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE listings ADD COLUMN archived_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE listings DROP COLUMN archived_at;
+ALTER TABLE users DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent is only STABLE, because its dictionary could change. Pinning the
+-- dictionary makes it usable in generated columns and indexes.
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text AS $$
+    SELECT public.unaccent('public.unaccent', $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+-- The 'simple' configuration neither stems nor drops stop words, which suits
+-- listings written in Vietnamese and English alike. It only splits on spaces
+-- and punctuation, so Chinese and Japanese text is searched by search_text.
+ALTER TABLE listings ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', immutable_unaccent(title)), 'A') ||
+    setweight(to_tsvector('simple', immutable_unaccent(location)), 'B') ||
+    setweight(to_tsvector('simple', immutable_unaccent(description)), 'C')
+) STORED;
+
+CREATE INDEX listings_search_vector_idx ON listings USING GIN (search_vector);
+CREATE INDEX listings_title_trgm_idx ON listings USING GIN (immutable_unaccent(lower(title)) gin_trgm_ops);
+CREATE INDEX listings_location_trgm_idx ON listings USING GIN (immutable_unaccent(lower(location)) gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX listings_location_trgm_idx;
+DROP INDEX listings_title_trgm_idx;
+DROP INDEX listings_search_vector_idx;
+ALTER TABLE listings DROP COLUMN search_vector;
+DROP FUNCTION immutable_unaccent(text);
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE listings ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90);
+ALTER TABLE listings ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180);
+CREATE INDEX listings_coordinates_idx ON listings (latitude, longitude);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX listings_coordinates_idx;
+ALTER TABLE listings DROP COLUMN longitude;
+ALTER TABLE listings DROP COLUMN latitude;
+-- +goose StatementEnd
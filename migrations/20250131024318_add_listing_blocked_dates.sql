@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE listing_blocked_dates (
+    id SERIAL PRIMARY KEY,
+    listing_id INT NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT listing_blocked_dates_dates_check CHECK (end_date > start_date),
+    -- Blocked nights are half-open ranges like booked ones, and never overlap:
+    -- blocking next to or over an existing range merges the two.
+    CONSTRAINT listing_blocked_dates_no_overlap
+        EXCLUDE USING gist (listing_id WITH =, daterange(start_date, end_date, '[)') WITH &&)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE listing_blocked_dates;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE listing_price_rules (
+    id SERIAL PRIMARY KEY,
+    listing_id INT NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('weekend', 'season', 'weekly', 'monthly')),
+    percent DECIMAL(5, 2) CHECK (percent >= 0),
+    price DECIMAL(10, 2) CHECK (price >= 0),
+    start_date DATE,
+    end_date DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- Seasons override the nightly price over a range of nights, every other
+    -- kind of rule is a percentage.
+    CONSTRAINT listing_price_rules_shape_check CHECK (
+        (kind = 'season' AND price IS NOT NULL AND start_date IS NOT NULL AND end_date > start_date)
+        OR (kind <> 'season' AND percent IS NOT NULL)
+    ),
+    CONSTRAINT listing_price_rules_no_overlap
+        EXCLUDE USING gist (listing_id WITH =, daterange(start_date, end_date, '[)') WITH &&) WHERE (kind = 'season')
+);
+
+CREATE UNIQUE INDEX listing_price_rules_kind_idx ON listing_price_rules (listing_id, kind) WHERE kind <> 'season';
+
+CREATE TABLE price_detail_nights (
+    id SERIAL PRIMARY KEY,
+    price_detail_id INT NOT NULL REFERENCES price_details(id) ON DELETE CASCADE,
+    night DATE NOT NULL,
+    base_price DECIMAL(10, 2) NOT NULL CHECK (base_price >= 0),
+    weekend_surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    UNIQUE (price_detail_id, night)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE price_detail_nights;
+DROP TABLE listing_price_rules;
+-- +goose StatementEnd
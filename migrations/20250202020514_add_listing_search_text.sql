@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Chinese and Japanese are written without spaces, so search_vector keeps a
+-- whole run of such text as one token. Words in those scripts are found as
+-- substrings of search_text instead, with a trigram index to keep it fast.
+ALTER TABLE listings ADD COLUMN search_text text GENERATED ALWAYS AS (
+    immutable_unaccent(lower(coalesce(title, '') || ' ' || coalesce(location, '') || ' ' || coalesce(description, '')))
+) STORED;
+
+CREATE INDEX listings_search_text_trgm_idx ON listings USING GIN (search_text gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX listings_search_text_trgm_idx;
+ALTER TABLE listings DROP COLUMN search_text;
+-- +goose StatementEnd
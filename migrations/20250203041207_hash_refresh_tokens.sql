@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Refresh tokens are looked up by their SHA-256 digest like every other
+-- token, so the ones issued before are hashed in place.
+UPDATE tokens SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex') WHERE name = 'refresh_token';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- The digests cannot be turned back into tokens, so everyone signs in again.
+DELETE FROM tokens WHERE name = 'refresh_token';
+-- +goose StatementEnd
@@ -0,0 +1,122 @@
+package geocode
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const FixtureName = "fixture"
+
+// DefaultPlaces are the cities the fixture geocoder knows, keyed by name.
+// Names in other scripts or spellings map to the same place.
+var DefaultPlaces = map[string]Point{
+	"Hà Nội":        {Latitude: 21.0285, Longitude: 105.8542},
+	"Hanoi":         {Latitude: 21.0285, Longitude: 105.8542},
+	"Hồ Chí Minh":   {Latitude: 10.8231, Longitude: 106.6297},
+	"Saigon":        {Latitude: 10.8231, Longitude: 106.6297},
+	"Đà Nẵng":       {Latitude: 16.0544, Longitude: 108.2022},
+	"Hội An":        {Latitude: 15.8801, Longitude: 108.3380},
+	"Huế":           {Latitude: 16.4637, Longitude: 107.5909},
+	"Nha Trang":     {Latitude: 12.2388, Longitude: 109.1967},
+	"Đà Lạt":        {Latitude: 11.9404, Longitude: 108.4583},
+	"Hải Phòng":     {Latitude: 20.8449, Longitude: 106.6881},
+	"Hạ Long":       {Latitude: 20.9517, Longitude: 107.0748},
+	"Sa Pa":         {Latitude: 22.3364, Longitude: 103.8438},
+	"Phú Quốc":      {Latitude: 10.2899, Longitude: 103.9840},
+	"Cần Thơ":       {Latitude: 10.0452, Longitude: 105.7469},
+	"Vũng Tàu":      {Latitude: 10.3460, Longitude: 107.0843},
+	"Tokyo":         {Latitude: 35.6762, Longitude: 139.6503},
+	"東京":            {Latitude: 35.6762, Longitude: 139.6503},
+	"Osaka":         {Latitude: 34.6937, Longitude: 135.5023},
+	"大阪":            {Latitude: 34.6937, Longitude: 135.5023},
+	"Kyoto":         {Latitude: 35.0116, Longitude: 135.7681},
+	"京都":            {Latitude: 35.0116, Longitude: 135.7681},
+	"Sapporo":       {Latitude: 43.0618, Longitude: 141.3545},
+	"札幌":            {Latitude: 43.0618, Longitude: 141.3545},
+	"Fukuoka":       {Latitude: 33.5904, Longitude: 130.4017},
+	"福岡":            {Latitude: 33.5904, Longitude: 130.4017},
+	"Yokohama":      {Latitude: 35.4437, Longitude: 139.6380},
+	"横浜":            {Latitude: 35.4437, Longitude: 139.6380},
+	"Nagoya":        {Latitude: 35.1815, Longitude: 136.9066},
+	"名古屋":           {Latitude: 35.1815, Longitude: 136.9066},
+	"Okinawa":       {Latitude: 26.2124, Longitude: 127.6809},
+	"沖縄":            {Latitude: 26.2124, Longitude: 127.6809},
+	"Bangkok":       {Latitude: 13.7563, Longitude: 100.5018},
+	"Singapore":     {Latitude: 1.3521, Longitude: 103.8198},
+	"Seoul":         {Latitude: 37.5665, Longitude: 126.9780},
+	"New York":      {Latitude: 40.7128, Longitude: -74.0060},
+	"San Francisco": {Latitude: 37.7749, Longitude: -122.4194},
+	"Los Angeles":   {Latitude: 34.0522, Longitude: -118.2437},
+	"London":        {Latitude: 51.5074, Longitude: -0.1278},
+	"Paris":         {Latitude: 48.8566, Longitude: 2.3522},
+	"Sydney":        {Latitude: -33.8688, Longitude: 151.2093},
+}
+
+// Fixture is an offline geocoder for development and tests. It resolves an
+// address to the longest known place name it contains, ignoring case and
+// accents, so "12 Phố Huế, Hà Nội" is placed in Hà Nội rather than Huế. Of
+// names as long, the one found first wins: "東京都" is Tokyo, not 京都.
+type Fixture struct {
+	places map[string]Point
+}
+
+func NewFixture(places map[string]Point) *Fixture {
+	f := &Fixture{places: make(map[string]Point, len(places))}
+
+	for name, point := range places {
+		f.places[normalize(name)] = point
+	}
+
+	return f
+}
+
+func (f *Fixture) Name() string {
+	return FixtureName
+}
+
+func (f *Fixture) Geocode(ctx context.Context, address string) (*Point, error) {
+	address = normalize(address)
+
+	best, bestAt := "", -1
+
+	for name := range f.places {
+		at := strings.Index(address, name)
+
+		if at < 0 {
+			continue
+		}
+
+		if len(name) > len(best) || (len(name) == len(best) && at < bestAt) {
+			best, bestAt = name, at
+		}
+	}
+
+	if bestAt < 0 {
+		return nil, ErrNotFound
+	}
+
+	point := f.places[best]
+
+	return &point, nil
+}
+
+// normalize lowercases s and strips its accents. Đ is a letter of its own
+// rather than an accented D, so it is mapped by hand.
+func normalize(s string) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ' || r == 'Đ':
+			r = 'd'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,41 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixtureGeocode(t *testing.T) {
+	geocoder := NewFixture(DefaultPlaces)
+	ctx := context.Background()
+
+	point, err := geocoder.Geocode(ctx, "12 Phố Huế, Hà Nội")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPlaces["Hà Nội"], *point)
+
+	point, err = geocoder.Geocode(ctx, "ha noi")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPlaces["Hà Nội"], *point)
+
+	point, err = geocoder.Geocode(ctx, "DA NANG, Vietnam")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPlaces["Đà Nẵng"], *point)
+
+	point, err = geocoder.Geocode(ctx, "東京都渋谷区")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPlaces["Tokyo"], *point)
+
+	_, err = geocoder.Geocode(ctx, "Atlantis")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestNew(t *testing.T) {
+	geocoder, err := New("")
+	assert.NoError(t, err)
+	assert.Equal(t, FixtureName, geocoder.Name())
+
+	_, err = New("unknown")
+	assert.Error(t, err)
+}
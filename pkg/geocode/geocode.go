@@ -0,0 +1,31 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("geocode: address not found")
+
+// Point is a position in degrees, WGS 84.
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Geocoder turns a free-text address into a position.
+type Geocoder interface {
+	Name() string
+	Geocode(ctx context.Context, address string) (*Point, error)
+}
+
+// New returns the geocoder registered under name.
+func New(name string) (Geocoder, error) {
+	switch name {
+	case "", FixtureName:
+		return NewFixture(DefaultPlaces), nil
+	default:
+		return nil, fmt.Errorf("geocode: unknown geocoder %q", name)
+	}
+}
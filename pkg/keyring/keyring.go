@@ -0,0 +1,253 @@
+// Package keyring holds the asymmetric keys access tokens are signed with.
+// One key signs new tokens while every key in the ring, including retired
+// ones, still verifies them, so keys can be rotated without invalidating the
+// tokens already handed out.
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinRSABits is the smallest RSA modulus accepted for signing keys.
+const MinRSABits = 2048
+
+var (
+	ErrUnknownKey     = errors.New("unknown key id")
+	ErrMethodMismatch = errors.New("signing method does not match key")
+)
+
+// Key is a signing key, or a verification-only key when Signer is nil.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	Signer crypto.Signer
+	Public crypto.PublicKey
+}
+
+// NewKey wraps a private key, choosing RS256 for RSA and EdDSA for Ed25519.
+func NewKey(id string, signer crypto.Signer) (*Key, error) {
+	key, err := NewVerificationKey(id, signer.Public())
+
+	if err != nil {
+		return nil, err
+	}
+
+	key.Signer = signer
+
+	return key, nil
+}
+
+// NewVerificationKey wraps a public key that only verifies tokens.
+func NewVerificationKey(id string, public crypto.PublicKey) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("key id is required")
+	}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < MinRSABits {
+			return nil, fmt.Errorf("rsa key %s is %d bits, at least %d are required", id, pub.N.BitLen(), MinRSABits)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Public: pub}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Public: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T for key %s", public, id)
+	}
+}
+
+// Generate creates a fresh Ed25519 signing key.
+func Generate(id string) (*Key, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+
+	return NewKey(id, private)
+}
+
+// ParseKey reads a PEM encoded key. PKCS#8 and PKCS#1 private keys can sign,
+// PKIX public keys can only verify.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", id)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing key %s: %w", id, err)
+		}
+
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T for key %s", private, id)
+		}
+
+		return NewKey(id, signer)
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing key %s: %w", id, err)
+		}
+
+		return NewKey(id, private)
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing key %s: %w", id, err)
+		}
+
+		return NewVerificationKey(id, public)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q for key %s", block.Type, id)
+	}
+}
+
+// LoadDir parses every *.pem file in dir. The file name without its
+// extension becomes the key id.
+func LoadDir(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys: %w", err)
+	}
+
+	var keys []*Key
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading key: %w", err)
+		}
+
+		key, err := ParseKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Keyring signs with one key and verifies with all of them.
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// New builds a keyring that signs with the key named signingId.
+func New(signingId string, keys ...*Key) (*Keyring, error) {
+	ring := &Keyring{keys: make(map[string]*Key, len(keys))}
+
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+
+	signing, ok := ring.keys[signingId]
+
+	if !ok {
+		return nil, fmt.Errorf("signing key %s: %w", signingId, ErrUnknownKey)
+	}
+
+	if signing.Signer == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", signingId)
+	}
+
+	ring.signing = signing
+
+	return ring, nil
+}
+
+// SigningKeyID returns the id of the key new tokens are signed with.
+func (r *Keyring) SigningKeyID() string {
+	return r.signing.ID
+}
+
+// Sign signs claims with the signing key and sets the kid header.
+func (r *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.signing.Method, claims)
+	token.Header["kid"] = r.signing.ID
+
+	signed, err := token.SignedString(r.signing.Signer)
+
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %w", err)
+	}
+
+	return signed, nil
+}
+
+// Keyfunc resolves the verification key of a token from its kid header. It
+// rejects tokens whose alg does not match the key, so a public key can never
+// be used as an HMAC secret.
+func (r *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := r.keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", kid, ErrUnknownKey)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("key %s expects %s, got %s: %w", kid, key.Method.Alg(), token.Method.Alg(), ErrMethodMismatch)
+	}
+
+	return key.Public, nil
+}
+
+// Methods lists the algorithms of the keys in the ring, for use with
+// jwt.WithValidMethods.
+func (r *Keyring) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+
+	for _, key := range r.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+// ids returns the key ids in a stable order.
+func (r *Keyring) ids() []string {
+	ids := make([]string, 0, len(r.keys))
+
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
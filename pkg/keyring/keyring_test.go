@@ -0,0 +1,187 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": 1, "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func parse(ring *Keyring, token string) (*jwt.Token, error) {
+	return jwt.Parse(token, ring.Keyfunc, jwt.WithValidMethods(ring.Methods()))
+}
+
+func rsaKey(t *testing.T, id string) *Key {
+	private, err := rsa.GenerateKey(rand.Reader, MinRSABits)
+	assert.NoError(t, err)
+
+	key, err := NewKey(id, private)
+	assert.NoError(t, err)
+
+	return key
+}
+
+func TestKeyring_SignAndVerify(t *testing.T) {
+	edKey, err := Generate("ed")
+	assert.NoError(t, err)
+
+	for _, key := range []*Key{edKey, rsaKey(t, "rsa")} {
+		ring, err := New(key.ID, key)
+		assert.NoError(t, err)
+
+		signed, err := ring.Sign(claims())
+		assert.NoError(t, err)
+
+		token, err := parse(ring, signed)
+		assert.NoError(t, err)
+		assert.True(t, token.Valid)
+		assert.Equal(t, key.ID, token.Header["kid"])
+		assert.Equal(t, key.Method.Alg(), token.Header["alg"])
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey, _ := Generate("2025-01")
+	newKey, _ := Generate("2025-02")
+
+	oldRing, err := New("2025-01", oldKey)
+	assert.NoError(t, err)
+
+	signed, err := oldRing.Sign(claims())
+	assert.NoError(t, err)
+
+	retired, err := NewVerificationKey(oldKey.ID, oldKey.Public)
+	assert.NoError(t, err)
+
+	newRing, err := New("2025-02", newKey, retired)
+	assert.NoError(t, err)
+
+	_, err = parse(newRing, signed)
+	assert.NoError(t, err)
+
+	dropped, err := New("2025-02", newKey)
+	assert.NoError(t, err)
+
+	_, err = parse(dropped, signed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestKeyring_RejectsMismatchedMethod(t *testing.T) {
+	key, _ := Generate("ed")
+	ring, _ := New("ed", key)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	token.Header["kid"] = "ed"
+
+	signed, err := token.SignedString([]byte(key.Public.(ed25519.PublicKey)))
+	assert.NoError(t, err)
+
+	_, err = jwt.Parse(signed, ring.Keyfunc)
+	assert.ErrorIs(t, err, ErrMethodMismatch)
+
+	_, err = parse(ring, signed)
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	key, _ := Generate("a")
+	public, _ := NewVerificationKey("b", key.Public)
+
+	_, err := New("c", key)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = New("b", key, public)
+	assert.Error(t, err)
+
+	_, err = New("a", key, key)
+	assert.Error(t, err)
+}
+
+func TestNewKey_RejectsShortRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	_, err = NewKey("short", private)
+	assert.Error(t, err)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "current.pem"), "PRIVATE KEY", der)
+
+	rsaPrivate, _ := rsa.GenerateKey(rand.Reader, MinRSABits)
+	der, err = x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	assert.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "retired.pem"), "PUBLIC KEY", der)
+
+	writePEM(t, filepath.Join(dir, "legacy.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPrivate))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0o600))
+
+	keys, err := LoadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	byId := map[string]*Key{}
+	for _, key := range keys {
+		byId[key.ID] = key
+	}
+
+	assert.Equal(t, jwt.SigningMethodEdDSA, byId["current"].Method)
+	assert.NotNil(t, byId["current"].Signer)
+	assert.Equal(t, jwt.SigningMethodRS256, byId["retired"].Method)
+	assert.Nil(t, byId["retired"].Signer)
+	assert.NotNil(t, byId["legacy"].Signer)
+
+	ring, err := New("current", keys...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"EdDSA", "RS256"}, ring.Methods())
+}
+
+func TestParseKey_Invalid(t *testing.T) {
+	_, err := ParseKey("bad", []byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestKeyring_JWKS(t *testing.T) {
+	edKey, _ := Generate("ed")
+	rsaSigning := rsaKey(t, "rsa")
+
+	ring, err := New("ed", edKey, rsaSigning)
+	assert.NoError(t, err)
+
+	set := ring.JWKS()
+	assert.Len(t, set.Keys, 2)
+
+	assert.Equal(t, "ed", set.Keys[0].Kid)
+	assert.Equal(t, "OKP", set.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", set.Keys[0].Crv)
+	assert.Equal(t, "EdDSA", set.Keys[0].Alg)
+	assert.Len(t, set.Keys[0].X, 43)
+
+	assert.Equal(t, "rsa", set.Keys[1].Kid)
+	assert.Equal(t, "RSA", set.Keys[1].Kty)
+	assert.Equal(t, "RS256", set.Keys[1].Alg)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+	assert.Equal(t, "sig", set.Keys[1].Use)
+}
+
+func writePEM(t *testing.T, path string, kind string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+}
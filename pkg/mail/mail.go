@@ -14,6 +14,10 @@ import (
 
 type Mail interface {
 	SendMailConfirmAccount(to string, token string) error
+	SendMailResetPassword(to string, token string) error
+	SendMailConfirmEmailChange(to string, token string) error
+	SendMailUnlockAccount(to string, token string) error
+	SendMailConfirmAccountDeletion(to string, token string) error
 }
 
 type mail struct {
@@ -22,6 +26,7 @@ type mail struct {
 	User       string
 	Pass       string
 	ServerHost string
+	ClientURL  string
 }
 
 func NewMailService() *mail {
@@ -33,6 +38,7 @@ func NewMailService() *mail {
 		User:       setting.MailUser,
 		Pass:       setting.MailPass,
 		ServerHost: "http://localhost:" + setting.Port,
+		ClientURL:  setting.ClientURL,
 	}
 }
 
@@ -57,33 +63,140 @@ func (m *mail) sendMail(to string, subject string, body string) error {
 }
 
 func (m *mail) SendMailConfirmAccount(to string, token string) error {
-	_, currentFile, _, _ := runtime.Caller(0)
+	confirmURL := m.ServerHost + "/api/v1/auth/confirm-account?token=" + token
 
-	rootDir := filepath.Join(filepath.Dir(currentFile), "..", "..")
+	content, err := renderTemplate("confirm_account.html", map[string]string{
+		"{{ ConfirmationURL }}": confirmURL,
+	})
+	if err != nil {
+		return err
+	}
 
-	templatePath := filepath.Join(rootDir, "templates", "confirm_account.html")
+	subject := "Confirm Your Account"
 
-	content, err := os.ReadFile(templatePath)
+	err = m.sendMail(to, subject, content)
 	if err != nil {
-		log.Msg.Errorf("failed to read email template: %v", err)
-		return fmt.Errorf("failed to read email template: %w", err)
+		log.Msg.Errorf("failed to send confirmation email: %v", err)
+		return fmt.Errorf("failed to send confirmation email: %w", err)
 	}
 
-	htmlContent := string(content)
+	log.Msg.Infof("Confirmation email sent successfully to %s", to)
 
-	confirmURL := m.ServerHost + "/api/v1/auth/confirm-account?token=" + token
+	return nil
+}
 
-	replacedContent := strings.Replace(htmlContent, "{{ ConfirmationURL }}", confirmURL, -1)
+func (m *mail) SendMailResetPassword(to string, token string) error {
+	resetURL := m.ClientURL + "/reset-password?token=" + token
 
-	subject := "Confirm Your Account"
+	content, err := renderTemplate("reset_password.html", map[string]string{
+		"{{ ResetURL }}": resetURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := "Reset Your Password"
 
-	err = m.sendMail(to, subject, replacedContent)
+	err = m.sendMail(to, subject, content)
 	if err != nil {
-		log.Msg.Errorf("failed to send confirmation email: %v", err)
-		return fmt.Errorf("failed to send confirmation email: %w", err)
+		log.Msg.Errorf("failed to send reset password email: %v", err)
+		return fmt.Errorf("failed to send reset password email: %w", err)
 	}
 
-	log.Msg.Infof("Confirmation email sent successfully to %s", to)
+	log.Msg.Infof("Reset password email sent successfully to %s", to)
+
+	return nil
+}
+
+func (m *mail) SendMailConfirmEmailChange(to string, token string) error {
+	confirmURL := m.ServerHost + "/api/v1/auth/confirm-email?token=" + token
+
+	content, err := renderTemplate("confirm_email_change.html", map[string]string{
+		"{{ ConfirmationURL }}": confirmURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := "Confirm Your New Email Address"
+
+	err = m.sendMail(to, subject, content)
+	if err != nil {
+		log.Msg.Errorf("failed to send email change confirmation: %v", err)
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+
+	log.Msg.Infof("Email change confirmation sent successfully to %s", to)
+
+	return nil
+}
+
+func (m *mail) SendMailUnlockAccount(to string, token string) error {
+	unlockURL := m.ServerHost + "/api/v1/auth/unlock?token=" + token
+
+	content, err := renderTemplate("unlock_account.html", map[string]string{
+		"{{ UnlockURL }}": unlockURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := "Your Account Has Been Locked"
+
+	err = m.sendMail(to, subject, content)
+	if err != nil {
+		log.Msg.Errorf("failed to send unlock account email: %v", err)
+		return fmt.Errorf("failed to send unlock account email: %w", err)
+	}
+
+	log.Msg.Infof("Unlock account email sent successfully to %s", to)
 
 	return nil
 }
+
+func (m *mail) SendMailConfirmAccountDeletion(to string, token string) error {
+	confirmURL := m.ClientURL + "/delete-account?token=" + token
+
+	content, err := renderTemplate("confirm_account_deletion.html", map[string]string{
+		"{{ ConfirmationURL }}": confirmURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := "Confirm Deleting Your Account"
+
+	err = m.sendMail(to, subject, content)
+	if err != nil {
+		log.Msg.Errorf("failed to send account deletion confirmation: %v", err)
+		return fmt.Errorf("failed to send account deletion confirmation: %w", err)
+	}
+
+	log.Msg.Infof("Account deletion confirmation sent successfully to %s", to)
+
+	return nil
+}
+
+// renderTemplate reads an HTML template from the templates directory and
+// substitutes its placeholders.
+func renderTemplate(name string, replacements map[string]string) (string, error) {
+	_, currentFile, _, _ := runtime.Caller(0)
+
+	rootDir := filepath.Join(filepath.Dir(currentFile), "..", "..")
+
+	templatePath := filepath.Join(rootDir, "templates", name)
+
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		log.Msg.Errorf("failed to read email template: %v", err)
+		return "", fmt.Errorf("failed to read email template: %w", err)
+	}
+
+	htmlContent := string(content)
+
+	for placeholder, value := range replacements {
+		htmlContent = strings.Replace(htmlContent, placeholder, value, -1)
+	}
+
+	return htmlContent, nil
+}
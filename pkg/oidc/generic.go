@@ -0,0 +1,292 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// genericProvider is an OpenID Connect issuer configured through discovery.
+// Its metadata and signing keys are fetched on first use and the keys are
+// refetched when a token names one that is not known yet.
+type genericProvider struct {
+	cfg Config
+
+	mu   sync.Mutex
+	meta *metadata
+	keys map[string]crypto.PublicKey
+}
+
+// New returns a provider for the OpenID Connect issuer in cfg.
+func New(cfg Config) Provider {
+	return &genericProvider{cfg: cfg}
+}
+
+func (p *genericProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *genericProvider) scopes() []string {
+	if len(p.cfg.Scopes) > 0 {
+		return p.cfg.Scopes
+	}
+	return defaultScopes
+}
+
+func (p *genericProvider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	meta := new(metadata)
+	endpoint := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+
+	if err := getJSON(ctx, p.cfg.client(), endpoint, "", meta); err != nil {
+		return nil, fmt.Errorf("error discovering %s: %w", p.cfg.Issuer, err)
+	}
+
+	if strings.TrimSuffix(meta.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("issuer mismatch: expected %s, got %s", p.cfg.Issuer, meta.Issuer)
+	}
+
+	p.meta = meta
+
+	return meta, nil
+}
+
+func (p *genericProvider) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return authCodeURL(meta.AuthorizationEndpoint, p.cfg, p.scopes(), state, verifier, url.Values{"nonce": {nonce}})
+}
+
+func (p *genericProvider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := exchangeCode(ctx, meta.TokenEndpoint, p.cfg, code, verifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id token in response", ErrExchange)
+	}
+
+	return p.verify(ctx, meta, token.IDToken, nonce)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string      `json:"nonce"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	PreferredUsername string      `json:"preferred_username"`
+	GivenName         string      `json:"given_name"`
+	FamilyName        string      `json:"family_name"`
+}
+
+func (p *genericProvider) verify(ctx context.Context, meta *metadata, idToken string, nonce string) (*Identity, error) {
+	claims := new(idTokenClaims)
+
+	_, err := jwt.ParseWithClaims(idToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.key(ctx, meta, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+
+	// Some issuers send email_verified as a string.
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+
+	return &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified && claims.Email != "",
+		Username:      claims.PreferredUsername,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+// key returns the issuer's key with the given id, refetching the key set
+// once when it is unknown so that key rotation at the issuer is picked up.
+func (p *genericProvider) key(ctx context.Context, meta *metadata, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	keys, err := fetchKeys(ctx, p.cfg, meta.JWKSURI)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = keys
+
+	key, ok := keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func fetchKeys(ctx context.Context, cfg Config, endpoint string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := getJSON(ctx, cfg.client(), endpoint, "", &set); err != nil {
+		return nil, fmt.Errorf("error fetching keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		public, err := key.public()
+
+		if err != nil {
+			// Skip keys of types we do not verify with.
+			continue
+		}
+
+		keys[key.Kid] = public
+	}
+
+	return keys, nil
+}
+
+func (k jwk) public() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
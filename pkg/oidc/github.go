@@ -0,0 +1,104 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Endpoint holds the OAuth 2.0 URLs of a provider without discovery.
+type Endpoint struct {
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+var GitHubEndpoint = Endpoint{
+	AuthURL:  "https://github.com/login/oauth/authorize",
+	TokenURL: "https://github.com/login/oauth/access_token",
+	APIURL:   "https://api.github.com",
+}
+
+var gitHubScopes = []string{"read:user", "user:email"}
+
+// gitHub signs in with GitHub, which has no id token; the identity is read
+// from the REST API with the access token instead.
+type gitHub struct {
+	cfg      Config
+	endpoint Endpoint
+}
+
+func NewGitHub(cfg Config) Provider {
+	return &gitHub{cfg: cfg, endpoint: GitHubEndpoint}
+}
+
+func (p *gitHub) Name() string {
+	return p.cfg.Name
+}
+
+func (p *gitHub) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	scopes := gitHubScopes
+
+	if len(p.cfg.Scopes) > 0 {
+		scopes = p.cfg.Scopes
+	}
+
+	return authCodeURL(p.endpoint.AuthURL, p.cfg, scopes, state, verifier, nil)
+}
+
+type gitHubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type gitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (p *gitHub) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.endpoint.TokenURL, p.cfg, code, verifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user := new(gitHubUser)
+
+	if err := getJSON(ctx, p.cfg.client(), p.endpoint.APIURL+"/user", token.AccessToken, user); err != nil {
+		return nil, fmt.Errorf("error fetching github user: %w", err)
+	}
+
+	if user.ID == 0 {
+		return nil, fmt.Errorf("error fetching github user: missing id")
+	}
+
+	var emails []gitHubEmail
+
+	if err := getJSON(ctx, p.cfg.client(), p.endpoint.APIURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return nil, fmt.Errorf("error fetching github emails: %w", err)
+	}
+
+	givenName, familyName, _ := strings.Cut(strings.TrimSpace(user.Name), " ")
+
+	identity := &Identity{
+		Provider:   p.cfg.Name,
+		Subject:    strconv.FormatInt(user.ID, 10),
+		Username:   user.Login,
+		GivenName:  givenName,
+		FamilyName: strings.TrimSpace(familyName),
+	}
+
+	// Prefer the primary address, but only ever one GitHub has verified.
+	for _, email := range emails {
+		if email.Verified && (email.Primary || identity.Email == "") {
+			identity.Email = email.Email
+			identity.EmailVerified = true
+		}
+	}
+
+	return identity, nil
+}
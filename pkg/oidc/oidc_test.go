@@ -0,0 +1,270 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockIssuer is a minimal OpenID Connect provider. It accepts a single
+// authorization code, bound to the PKCE challenge of verifier.
+type mockIssuer struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	kid      string
+	code     string
+	verifier string
+	claims   jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	m := &mockIssuer{key: key, kid: "key-1", code: "good-code", verifier: "the-verifier"}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": m.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		if r.Form.Get("code") != m.code || r.Form.Get("code_verifier") != m.verifier || r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims)
+		token.Header["kid"] = m.kid
+		signed, _ := token.SignedString(m.key)
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     signed,
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	m.claims = jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            "client",
+		"sub":            "user-42",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "the-nonce",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"given_name":     "Alice",
+		"family_name":    "Smith",
+	}
+
+	return m
+}
+
+func (m *mockIssuer) provider() Provider {
+	return New(Config{
+		Name:         "mock",
+		Issuer:       m.server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+	})
+}
+
+func TestGeneric_AuthCodeURL(t *testing.T) {
+	m := newMockIssuer(t)
+
+	raw, err := m.provider().AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.NoError(t, err)
+
+	u, err := url.Parse(raw)
+	assert.NoError(t, err)
+
+	query := u.Query()
+	assert.Equal(t, m.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "client", query.Get("client_id"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, Challenge("verifier"), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestGeneric_Exchange(t *testing.T) {
+	m := newMockIssuer(t)
+
+	identity, err := m.provider().Exchange(context.Background(), m.code, m.verifier, "the-nonce")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "mock",
+		Subject:       "user-42",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		GivenName:     "Alice",
+		FamilyName:    "Smith",
+	}, identity)
+}
+
+func TestGeneric_ExchangeRejects(t *testing.T) {
+	ctx := context.Background()
+
+	m := newMockIssuer(t)
+	_, err := m.provider().Exchange(ctx, "bad-code", m.verifier, "the-nonce")
+	assert.ErrorIs(t, err, ErrExchange)
+
+	_, err = m.provider().Exchange(ctx, m.code, "wrong-verifier", "the-nonce")
+	assert.ErrorIs(t, err, ErrExchange)
+
+	_, err = m.provider().Exchange(ctx, m.code, m.verifier, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	m.claims["aud"] = "someone-else"
+	_, err = m.provider().Exchange(ctx, m.code, m.verifier, "the-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	m = newMockIssuer(t)
+	m.claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = m.provider().Exchange(ctx, m.code, m.verifier, "the-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	m = newMockIssuer(t)
+	m.claims["iss"] = "https://evil.example.com"
+	_, err = m.provider().Exchange(ctx, m.code, m.verifier, "the-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestGeneric_KeyRotation(t *testing.T) {
+	m := newMockIssuer(t)
+	provider := m.provider()
+	ctx := context.Background()
+
+	_, err := provider.Exchange(ctx, m.code, m.verifier, "the-nonce")
+	assert.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	m.key = key
+	m.kid = "key-2"
+
+	_, err = provider.Exchange(ctx, m.code, m.verifier, "the-nonce")
+	assert.NoError(t, err)
+}
+
+func TestGeneric_UnverifiedEmail(t *testing.T) {
+	m := newMockIssuer(t)
+	m.claims["email_verified"] = "false"
+
+	identity, err := m.provider().Exchange(context.Background(), m.code, m.verifier, "the-nonce")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", identity.Email)
+	assert.False(t, identity.EmailVerified)
+
+	m.claims["email_verified"] = "true"
+
+	identity, err = m.provider().Exchange(context.Background(), m.code, m.verifier, "the-nonce")
+
+	assert.NoError(t, err)
+	assert.True(t, identity.EmailVerified)
+}
+
+func TestGitHub_Exchange(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		if r.Form.Get("code") != "good-code" || r.Header.Get("Accept") != "application/json" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gh-token", "token_type": "bearer"})
+	})
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1234, "login": "octocat", "name": "Mona Lisa Octocat"})
+	})
+
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "unverified@example.com", "primary": false, "verified": false},
+			{"email": "mona@example.com", "primary": true, "verified": true},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := &gitHub{
+		cfg: Config{Name: "github", ClientID: "client", ClientSecret: "secret"},
+		endpoint: Endpoint{
+			AuthURL:  server.URL + "/login/oauth/authorize",
+			TokenURL: server.URL + "/login/oauth/access_token",
+			APIURL:   server.URL,
+		},
+	}
+
+	identity, err := provider.Exchange(context.Background(), "good-code", "verifier", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "github",
+		Subject:       "1234",
+		Email:         "mona@example.com",
+		EmailVerified: true,
+		Username:      "octocat",
+		GivenName:     "Mona",
+		FamilyName:    "Lisa Octocat",
+	}, identity)
+
+	_, err = provider.Exchange(context.Background(), "bad-code", "verifier", "")
+	assert.ErrorIs(t, err, ErrExchange)
+
+	raw, err := provider.AuthCodeURL(context.Background(), "state", "", "verifier")
+	assert.NoError(t, err)
+	assert.Contains(t, raw, "scope=read%3Auser+user%3Aemail")
+}
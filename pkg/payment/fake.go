@@ -0,0 +1,142 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+)
+
+const FakeProviderName = "fake"
+
+// FakeProvider is an in-process gateway that never talks to the network.
+// References are generated from a counter, so the same sequence of calls
+// always yields the same results. Intents only live in memory and are lost
+// on restart, after which they can no longer be captured or refunded, so it
+// must not be used outside tests and development.
+type FakeProvider struct {
+	mu      sync.Mutex
+	secret  []byte
+	intents map[string]*Intent
+	seq     int
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret:  []byte(secret),
+		intents: make(map[string]*Intent),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) Authorize(amount float64, currency string, description string) (*Intent, error) {
+	if amount <= 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, ErrInvalidAmount
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent := &Intent{
+		Reference: p.nextReference("pi"),
+		Amount:    amount,
+		Currency:  currency,
+		Status:    StatusAuthorized,
+	}
+
+	p.intents[intent.Reference] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Capture(reference string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[reference]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	if intent.Status != StatusAuthorized {
+		return nil, ErrInvalidState
+	}
+
+	intent.Status = StatusCaptured
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Refund(reference string, amount float64) (*Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[reference]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	if intent.Status != StatusCaptured && intent.Status != StatusPartiallyRefunded {
+		return nil, ErrInvalidState
+	}
+
+	if amount <= 0 || cents(amount) > cents(intent.Amount)-cents(intent.Refunded) {
+		return nil, ErrInvalidAmount
+	}
+
+	intent.Refunded = float64(cents(intent.Refunded)+cents(amount)) / 100
+
+	if cents(intent.Refunded) == cents(intent.Amount) {
+		intent.Status = StatusRefunded
+	} else {
+		intent.Status = StatusPartiallyRefunded
+	}
+
+	return &Refund{
+		Reference:       p.nextReference("re"),
+		IntentReference: reference,
+		Amount:          amount,
+	}, nil
+}
+
+// VerifyWebhook checks the payload was signed with the provider's secret.
+// Without a secret every signature is rejected, since anyone could sign
+// with an empty key.
+func (p *FakeProvider) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	if len(p.secret) == 0 || !hmac.Equal([]byte(p.Sign(payload)), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	event := new(Event)
+
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("payment: invalid webhook payload: %w", err)
+	}
+
+	return event, nil
+}
+
+// Sign returns the signature the fake gateway attaches to webhook payloads,
+// so tests and local tooling can forge valid events.
+func (p *FakeProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *FakeProvider) nextReference(prefix string) string {
+	p.seq++
+	return fmt.Sprintf("fake_%s_%06d", prefix, p.seq)
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeProvider_AuthorizeCaptureRefund(t *testing.T) {
+	provider := NewFakeProvider("secret")
+
+	intent, err := provider.Authorize(250, "USD", "Booking #1")
+	assert.NoError(t, err)
+	assert.Equal(t, "fake_pi_000001", intent.Reference)
+	assert.Equal(t, StatusAuthorized, intent.Status)
+
+	captured, err := provider.Capture(intent.Reference)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCaptured, captured.Status)
+
+	_, err = provider.Capture(intent.Reference)
+	assert.ErrorIs(t, err, ErrInvalidState)
+
+	refund, err := provider.Refund(intent.Reference, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, "fake_re_000002", refund.Reference)
+	assert.Equal(t, 100.0, refund.Amount)
+
+	_, err = provider.Refund(intent.Reference, 200)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = provider.Refund(intent.Reference, 150)
+	assert.NoError(t, err)
+}
+
+func TestFakeProvider_IsDeterministic(t *testing.T) {
+	first := NewFakeProvider("secret")
+	second := NewFakeProvider("secret")
+
+	a, _ := first.Authorize(10, "USD", "")
+	b, _ := second.Authorize(10, "USD", "")
+
+	assert.Equal(t, a.Reference, b.Reference)
+}
+
+func TestFakeProvider_Errors(t *testing.T) {
+	provider := NewFakeProvider("secret")
+
+	_, err := provider.Authorize(0, "USD", "")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = provider.Capture("unknown")
+	assert.ErrorIs(t, err, ErrIntentNotFound)
+
+	intent, _ := provider.Authorize(10, "USD", "")
+
+	_, err = provider.Refund(intent.Reference, 5)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
+
+func TestFakeProvider_VerifyWebhook(t *testing.T) {
+	provider := NewFakeProvider("secret")
+
+	payload, _ := json.Marshal(Event{Type: EventCaptured, Reference: "fake_pi_000001", Amount: 10})
+
+	event, err := provider.VerifyWebhook(payload, provider.Sign(payload))
+	assert.NoError(t, err)
+	assert.Equal(t, EventCaptured, event.Type)
+	assert.Equal(t, "fake_pi_000001", event.Reference)
+
+	_, err = provider.VerifyWebhook(payload, NewFakeProvider("other").Sign(payload))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider("fake", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, FakeProviderName, provider.Name())
+
+	_, err = NewProvider("unknown", "secret")
+	assert.Error(t, err)
+}
+
+func TestFakeProvider_VerifyWebhookEmptySecret(t *testing.T) {
+	provider := NewFakeProvider("")
+
+	payload, _ := json.Marshal(Event{Type: EventRefunded, Reference: "fake_pi_000001", Amount: 10})
+
+	_, err := provider.VerifyWebhook(payload, provider.Sign(payload))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = NewProvider("fake", "")
+	assert.ErrorIs(t, err, ErrMissingSecret)
+}
@@ -0,0 +1,85 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Status string
+
+const (
+	StatusPending           Status = "pending"
+	StatusAuthorized        Status = "authorized"
+	StatusCaptured          Status = "captured"
+	StatusRefunded          Status = "refunded"
+	StatusPartiallyRefunded Status = "partially_refunded"
+	StatusFailed            Status = "failed"
+)
+
+const (
+	EventCaptured = "payment.captured"
+	EventRefunded = "payment.refunded"
+	EventFailed   = "payment.failed"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("payment: invalid amount")
+	ErrIntentNotFound   = errors.New("payment: intent not found")
+	ErrInvalidState     = errors.New("payment: intent is not in a valid state for this operation")
+	ErrInvalidSignature = errors.New("payment: invalid webhook signature")
+	ErrMissingSecret    = errors.New("payment: webhook secret must not be empty")
+)
+
+// RefundStatus is the status of a charge of amount once refunded of it has
+// been paid back, compared to the cent.
+func RefundStatus(amount float64, refunded float64) Status {
+	if cents(refunded) >= cents(amount) {
+		return StatusRefunded
+	}
+	return StatusPartiallyRefunded
+}
+
+// Intent is a payment held or taken from a guest by the provider.
+type Intent struct {
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+	Refunded  float64 `json:"refunded"`
+	Currency  string  `json:"currency"`
+	Status    Status  `json:"status"`
+}
+
+type Refund struct {
+	Reference       string  `json:"reference"`
+	IntentReference string  `json:"intent_reference"`
+	Amount          float64 `json:"amount"`
+}
+
+// Event is a notification pushed by the provider to our webhook endpoint.
+type Event struct {
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+}
+
+type Provider interface {
+	Name() string
+	Authorize(amount float64, currency string, description string) (*Intent, error)
+	Capture(reference string) (*Intent, error)
+	Refund(reference string, amount float64) (*Refund, error)
+	VerifyWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// NewProvider returns the provider registered under name. Webhooks are
+// signed with secret, which must not be empty.
+func NewProvider(name string, secret string) (Provider, error) {
+	if secret == "" {
+		return nil, ErrMissingSecret
+	}
+
+	switch name {
+	case "", FakeProviderName:
+		return NewFakeProvider(secret), nil
+	default:
+		return nil, fmt.Errorf("payment: unknown provider %q", name)
+	}
+}
@@ -0,0 +1,14 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundStatus(t *testing.T) {
+	assert.Equal(t, StatusRefunded, RefundStatus(335.1, 335.1))
+	assert.Equal(t, StatusRefunded, RefundStatus(0.3, 0.1+0.2))
+	assert.Equal(t, StatusPartiallyRefunded, RefundStatus(335.1, 167.55))
+	assert.Equal(t, StatusPartiallyRefunded, RefundStatus(335.1, 335.09))
+}
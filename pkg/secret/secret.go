@@ -0,0 +1,35 @@
+// Package secret generates random single-use tokens and the digests that are
+// stored in place of them.
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// TokenBytes is the amount of randomness in a token, hex encoded to twice as
+// many characters.
+const TokenBytes = 32
+
+// NewToken returns a random token to hand out and the hash to persist.
+func NewToken() (string, string, error) {
+	buf := make([]byte, TokenBytes)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("error generating token: %w", err)
+	}
+
+	token := hex.EncodeToString(buf)
+
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex encoded SHA-256 digest of token. Tokens carry enough
+// entropy that a fast unsalted hash is sufficient and keeps lookups by hash
+// possible.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
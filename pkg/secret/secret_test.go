@@ -0,0 +1,25 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewToken(t *testing.T) {
+	token, hash, err := NewToken()
+
+	assert.NoError(t, err)
+	assert.Len(t, token, TokenBytes*2)
+	assert.Equal(t, Hash(token), hash)
+	assert.NotEqual(t, token, hash)
+
+	other, _, err := NewToken()
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Hash(""))
+}
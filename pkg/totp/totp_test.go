@@ -0,0 +1,96 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238(t *testing.T) {
+	// The RFC lists eight digit codes; the last six digits are the same.
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, c := range cases {
+		code, err := Code(rfcSecret, Counter(time.Unix(c.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, code, "time %d", c.unix)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	counter, ok := Verify(rfcSecret, "050471", now)
+	assert.True(t, ok)
+	assert.Equal(t, Counter(now), counter)
+
+	_, ok = Verify(rfcSecret, "050 471", now.Add(Period))
+	assert.True(t, ok)
+
+	_, ok = Verify(rfcSecret, "050471", now.Add(2*Period))
+	assert.False(t, ok)
+
+	_, ok = Verify(rfcSecret, "123456", now)
+	assert.False(t, ok)
+
+	_, ok = Verify(rfcSecret, "", now)
+	assert.False(t, ok)
+
+	_, ok = Verify("not base32!", "050471", now)
+	assert.False(t, ok)
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := Code(secret, Counter(time.Now()))
+	assert.NoError(t, err)
+
+	_, ok := Verify(secret, code, time.Now())
+	assert.True(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Booking", "alice@example.com", "JBSWY3DPEHPK3PXP")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Booking:alice@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Booking")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+
+	seen := map[string]bool{}
+
+	for _, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, byte('-'), code[5])
+		assert.Equal(t, code, NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))))
+		seen[code] = true
+	}
+
+	assert.Len(t, seen, 10)
+}
@@ -0,0 +1,18 @@
+#!/bin/bash
+
+if [ "$#" -ne 1 ]; then
+  echo "Usage: $0 <key_id>"
+  echo "Example: $0 2025-01"
+  exit 1
+fi
+
+cd ..
+
+KEY_ID=$1
+
+KEYS_DIR="./keys"
+
+mkdir -p "$KEYS_DIR"
+
+openssl genpkey -algorithm ed25519 -out "$KEYS_DIR/$KEY_ID.pem"
+chmod 600 "$KEYS_DIR/$KEY_ID.pem"